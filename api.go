@@ -0,0 +1,91 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+// API is the set of operations provided by the WSL API. It allows
+// code using this package to substitute a fake for testing.
+type API interface {
+	Configure(name string, defaultUID uint32, flags DistributionFlags) error
+	GetConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error)
+	IsRegistered(name string) bool
+	Launch(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (process Handle, err error)
+	LaunchInteractive(name string, command string, useCwd bool) (exitCode uint32, err error)
+	Register(name string, tarball string) error
+	Unregister(name string) error
+}
+
+// Default is the API used by the package functions wrapping the WSL
+// API. It is backed by wslapi.dll.
+var Default API = syscallAPI{}
+
+// ConfigureDistribution Modifies the behavior of a distribution
+// registered with the Windows Subsystem for Linux.
+//
+// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslconfiguredistribution
+func ConfigureDistribution(name string, defaultUID uint32, flags DistributionFlags) error {
+	return Default.Configure(name, defaultUID, flags)
+}
+
+// GetDistributionConfiguration retrieves the current configuration of
+// a distribution registered with the Windows Subsystem for Linux.
+//
+// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslgetdistributionconfiguration
+func GetDistributionConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
+	return Default.GetConfiguration(name)
+}
+
+// IsDistributionRegistered determines if a distribution is registered with the Windows Subsystem for Linux.
+//
+// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslisdistributionregistered
+func IsDistributionRegistered(name string) bool {
+	return Default.IsRegistered(name)
+}
+
+// Launch launches a Windows Subsystem for Linux (WSL) process in the context of a particular distribution.
+// If the process cannot be started, the error is a *LaunchError.
+//
+// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wsllaunch
+func Launch(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (process Handle, err error) {
+	return Default.Launch(name, command, useCwd, stdin, stdout, stderr)
+}
+
+// LaunchInteractive Launches an interactive Windows Subsystem for
+// Linux (WSL) process in the context of a particular distribution.
+// This differs from Launch in that the end user will be able to
+// interact with the newly-created process. If the process cannot be
+// started, the error is a *LaunchError.
+//
+// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wsllaunchinteractive
+func LaunchInteractive(name string, command string, useCwd bool) (exitCode uint32, err error) {
+	return Default.LaunchInteractive(name, command, useCwd)
+}
+
+// RegisterDistribution registers a new distribution with the Windows
+// Subsystem for Linux.
+//
+// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslregisterdistribution
+func RegisterDistribution(name string, tarball string) error {
+	return Default.Register(name, tarball)
+}
+
+// UnregisterDistribution unregisters a distribution from the Windows
+// Subsystem for Linux.
+//
+// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslunregisterdistribution
+func UnregisterDistribution(name string) error {
+	return Default.Unregister(name)
+}
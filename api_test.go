@@ -0,0 +1,104 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"sync"
+	"testing"
+)
+
+// fakeAPI is an in-memory API for testing code built on the package
+// functions.
+type fakeAPI struct {
+	mu            sync.Mutex
+	distributions map[string]*DistributionConfig
+}
+
+// useFakeAPI makes the package functions use a fakeAPI with the given
+// distributions for the duration of the test.
+func useFakeAPI(t *testing.T, distributions map[string]*DistributionConfig) *fakeAPI {
+	t.Helper()
+	f := &fakeAPI{distributions: distributions}
+	old := Default
+	Default = f
+	t.Cleanup(func() { Default = old })
+	return f
+}
+
+func (f *fakeAPI) get(name string) (*DistributionConfig, error) {
+	d, ok := f.distributions[name]
+	if !ok {
+		return nil, ErrDistributionNotFound
+	}
+	return d, nil
+}
+
+func (f *fakeAPI) Configure(name string, defaultUID uint32, flags DistributionFlags) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, err := f.get(name)
+	if err != nil {
+		return err
+	}
+	d.DefaultUID, d.Flags = defaultUID, flags
+	return nil
+}
+
+func (f *fakeAPI) GetConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, err := f.get(name)
+	if err != nil {
+		return
+	}
+	return d.Version, d.DefaultUID, d.Flags, d.Environment, nil
+}
+
+func (f *fakeAPI) IsRegistered(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err := f.get(name)
+	return err == nil
+}
+
+func (f *fakeAPI) Launch(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (Handle, error) {
+	return InvalidHandle, ErrUnsupportedPlatform
+}
+
+func (f *fakeAPI) LaunchInteractive(name string, command string, useCwd bool) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func (f *fakeAPI) Register(name string, tarball string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.distributions[name]; ok {
+		return ErrAlreadyExists
+	}
+	f.distributions[name] = &DistributionConfig{Version: 2}
+	return nil
+}
+
+func (f *fakeAPI) Unregister(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.get(name); err != nil {
+		return err
+	}
+	delete(f.distributions, name)
+	return nil
+}
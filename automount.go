@@ -0,0 +1,62 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import "golang.org/x/sys/windows"
+
+// ExpectedAutomountDrives returns the mount points, such as /mnt/c,
+// at which WSL will mount the host's fixed drives if drive mounting
+// is enabled for a distribution. Network and removable drives are
+// not mounted automatically and are therefore not included.
+//
+// The default automount root /mnt/ is assumed; a distribution may
+// override it in /etc/wsl.conf.
+func ExpectedAutomountDrives() ([]string, error) {
+	mask, err := logicalDrives()
+	if err != nil {
+		return nil, err
+	}
+	var mounts []string
+	for i := 0; i < 26; i++ {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		t, err := driveType(string(rune('A'+i)) + `:\`)
+		if err != nil {
+			return nil, err
+		}
+		if t == windows.DRIVE_FIXED {
+			mounts = append(mounts, "/mnt/"+string(rune('a'+i)))
+		}
+	}
+	return mounts, nil
+}
+
+// logicalDrives and driveType enumerate the host's drives for
+// ExpectedAutomountDrives; tests replace them.
+var (
+	logicalDrives = windows.GetLogicalDrives
+	driveType     = func(root string) (uint32, error) {
+		p, err := windows.UTF16PtrFromString(root)
+		if err != nil {
+			return 0, err
+		}
+		return windows.GetDriveType(p), nil
+	}
+)
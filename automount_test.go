@@ -0,0 +1,83 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+// useFakeDrives makes ExpectedAutomountDrives see the drives in types,
+// keyed by root directory, for the duration of the test.
+func useFakeDrives(t *testing.T, types map[string]uint32) {
+	t.Helper()
+	oldDrives, oldType := logicalDrives, driveType
+	t.Cleanup(func() { logicalDrives, driveType = oldDrives, oldType })
+	logicalDrives = func() (uint32, error) {
+		var mask uint32
+		for root := range types {
+			mask |= 1 << uint(root[0]-'A')
+		}
+		return mask, nil
+	}
+	driveType = func(root string) (uint32, error) {
+		if t, ok := types[root]; ok {
+			return t, nil
+		}
+		return windows.DRIVE_NO_ROOT_DIR, nil
+	}
+}
+
+func TestExpectedAutomountDrives(t *testing.T) {
+	useFakeDrives(t, map[string]uint32{
+		`A:\`: windows.DRIVE_REMOVABLE,
+		`C:\`: windows.DRIVE_FIXED,
+		`D:\`: windows.DRIVE_CDROM,
+		`E:\`: windows.DRIVE_FIXED,
+		`N:\`: windows.DRIVE_REMOTE,
+		`R:\`: windows.DRIVE_RAMDISK,
+		`Z:\`: windows.DRIVE_FIXED,
+	})
+	got, err := ExpectedAutomountDrives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/mnt/c", "/mnt/e", "/mnt/z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExpectedAutomountDrivesNone(t *testing.T) {
+	useFakeDrives(t, map[string]uint32{`N:\`: windows.DRIVE_REMOTE})
+	if got, err := ExpectedAutomountDrives(); err != nil || len(got) != 0 {
+		t.Errorf("got %q, %v; want no drives", got, err)
+	}
+}
+
+func TestExpectedAutomountDrivesError(t *testing.T) {
+	useFakeDrives(t, nil)
+	errDrives := errors.New("enumeration failed")
+	logicalDrives = func() (uint32, error) { return 0, errDrives }
+	if _, err := ExpectedAutomountDrives(); !errors.Is(err, errDrives) {
+		t.Errorf("got %v, want %v", err, errDrives)
+	}
+}
@@ -0,0 +1,69 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"os"
+	"sync"
+)
+
+// LaunchAll runs command in all registered distributions in parallel
+// and waits for all of them to finish. Standard input is connected to
+// the null device; standard output and standard error are those of
+// the current process, so the output of the processes is interleaved.
+//
+// The exit codes of processes that ran are returned by distribution
+// name, as are the errors for distributions in which the command could
+// not be run. If the distributions cannot be enumerated, the error is
+// returned under the empty name.
+func LaunchAll(command string, useCwd bool) (map[string]uint32, map[string]error) {
+	exitCodes, errs := make(map[string]uint32), make(map[string]error)
+	names, err := ListDistributions()
+	if err != nil {
+		errs[""] = err
+		return exitCodes, errs
+	}
+	nul, err := os.Open(os.DevNull)
+	if err != nil {
+		errs[""] = err
+		return exitCodes, errs
+	}
+	defer nul.Close()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			var exitCode uint32
+			process, err := LaunchProcess(name, command, useCwd,
+				Handle(nul.Fd()), Handle(os.Stdout.Fd()), Handle(os.Stderr.Fd()))
+			if err == nil {
+				exitCode, err = process.Wait()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[name] = err
+			} else {
+				exitCodes[name] = exitCode
+			}
+		}(name)
+	}
+	wg.Wait()
+	return exitCodes, errs
+}
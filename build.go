@@ -0,0 +1,49 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// currentVersionKey is the registry key below HKEY_LOCAL_MACHINE that
+// describes the running Windows version.
+const currentVersionKey = `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+
+// WindowsBuild returns the build number of the running Windows
+// version as recorded in the CurrentBuildNumber registry value.
+func WindowsBuild() (int, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, currentVersionKey, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, err
+	}
+	defer k.Close()
+	s, _, err := k.GetStringValue("CurrentBuildNumber")
+	if err != nil {
+		return 0, err
+	}
+	build, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("CurrentBuildNumber %q: %w", s, err)
+	}
+	return build, nil
+}
@@ -0,0 +1,80 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// supportBundleCommands lists the files of a support bundle that are
+// collected by running a command inside the distribution.
+var supportBundleCommands = []struct {
+	file, script string
+}{
+	{"wsl.conf", "cat /etc/wsl.conf"},
+	{"os-release", "cat /etc/os-release"},
+	{"uname.txt", "uname -a"},
+	{"df.txt", "df -h"},
+	{"mount.txt", "mount"},
+	{"dmesg.txt", "dmesg | tail -n 200"},
+}
+
+// SupportBundle writes a zip archive to w containing information
+// useful for diagnosing problems with the distribution: its registry
+// values, its configuration, /etc/wsl.conf, /etc/os-release, and the
+// output of uname, df, mount, and dmesg. Collecting each piece is
+// best-effort; if it fails, the error is recorded in the respective
+// file instead. Only errors writing the archive are returned.
+func (d Distribution) SupportBundle(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	add := func(file string, data []byte, err error) error {
+		f, werr := zw.Create(file)
+		if werr != nil {
+			return werr
+		}
+		if err != nil {
+			data = append(data, fmt.Sprintf("\nerror: %v\n", err)...)
+		}
+		_, werr = f.Write(data)
+		return werr
+	}
+	data, err := lxssValues(d.Name)
+	if err := add("registry.txt", data, err); err != nil {
+		return err
+	}
+	var cfg bytes.Buffer
+	version, uid, flags, env, err := GetDistributionConfiguration(d.Name)
+	if err == nil {
+		fmt.Fprintf(&cfg, "Version = %d\nDefaultUID = %d\nFlags = %v\n", version, uid, flags)
+		for _, e := range env {
+			fmt.Fprintf(&cfg, "Environment = %s\n", e)
+		}
+	}
+	if err := add("configuration.txt", cfg.Bytes(), err); err != nil {
+		return err
+	}
+	for _, c := range supportBundleCommands {
+		out, err := LaunchCombinedOutput(d.Name, shellCommand(c.script), false)
+		if err := add(c.file, out, err); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,98 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSupportBundle(t *testing.T) {
+	useFakeAPI(t, map[string]*DistributionConfig{"test": {
+		Version:     2,
+		DefaultUID:  1000,
+		Flags:       DISTRIBUTION_FLAGS_ENABLE_INTEROP | DISTRIBUTION_FLAGS_APPEND_NT_PATH,
+		Environment: []string{"HOSTTYPE=x86_64", "LANG=en_US.UTF-8"},
+	}})
+	var buf bytes.Buffer
+	if err := (Distribution{Name: "test"}).SupportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	contents := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+		contents[f.Name] = string(data)
+	}
+	want := []string{"registry.txt", "configuration.txt", "wsl.conf", "os-release",
+		"uname.txt", "df.txt", "mount.txt", "dmesg.txt"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("entries %q, want %q", names, want)
+	}
+	if got, want := contents["configuration.txt"], "Version = 2\nDefaultUID = 1000\n"+
+		"Flags = ENABLE_INTEROP|APPEND_NT_PATH\n"+
+		"Environment = HOSTTYPE=x86_64\nEnvironment = LANG=en_US.UTF-8\n"; got != want {
+		t.Errorf("configuration.txt = %q, want %q", got, want)
+	}
+	// The fake cannot launch processes or provide registry values,
+	// which must not abort the bundle.
+	for _, name := range want {
+		if name != "configuration.txt" && !strings.Contains(contents[name], "\nerror: ") {
+			t.Errorf("%s = %q, want an error", name, contents[name])
+		}
+	}
+}
+
+func TestSupportBundleNotFound(t *testing.T) {
+	useFakeAPI(t, map[string]*DistributionConfig{})
+	var buf bytes.Buffer
+	if err := (Distribution{Name: "missing"}).SupportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "configuration.txt" {
+			continue
+		}
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if !strings.Contains(string(data), ErrDistributionNotFound.Error()) {
+			t.Errorf("configuration.txt = %q, want %v", data, ErrDistributionNotFound)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// comCall runs fn with COM initialized on the current OS thread,
+// retrying transient failures according to SetRetryPolicy. HRESULT
+// errors are decoded using decodeHRESULT.
+//
+// The WSL API is implemented on top of COM, which requires
+// CoInitializeEx to have been called on the calling thread. Since a
+// goroutine may be moved to a different thread at any time, the
+// goroutine is locked to its thread for the duration of the call.
+// COM is initialized for the multithreaded apartment; calls to
+// CoInitializeEx are reference-counted, so this is a no-op apart from
+// the bookkeeping if the thread has already been initialized. A
+// thread that has been initialized for a single-threaded apartment by
+// the caller is used as is.
+func comCall(fn func() error) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	uninit, err := initCOM()
+	if err != nil {
+		return err
+	}
+	defer uninit()
+	attempts, maxWait := getRetryPolicy()
+	deadline := time.Now().Add(maxWait)
+	delay := retryInitialDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if !isTransient(err) || attempt >= attempts || time.Now().Add(delay).After(deadline) {
+			return decodeHRESULT(err)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// transientHRESULTs lists HRESULTs returned while the WSL service is
+// starting or busy, after which a call may be retried.
+var transientHRESULTs = map[syscall.Errno]bool{
+	0x8001010A: true, // RPC_E_SERVERCALL_RETRYLATER
+	0x80010001: true, // RPC_E_CALL_REJECTED
+	0x800706BA: true, // HRESULT_FROM_WIN32(RPC_S_SERVER_UNAVAILABLE)
+	0x800706BB: true, // HRESULT_FROM_WIN32(RPC_S_SERVER_TOO_BUSY)
+}
+
+// isTransient reports whether err is one of transientHRESULTs.
+func isTransient(err error) bool {
+	hr, ok := err.(syscall.Errno)
+	return ok && transientHRESULTs[hr]
+}
+
+// retryInitialDelay is the delay before the first retry; it doubles
+// with each further retry.
+const retryInitialDelay = 50 * time.Millisecond
+
+var retryPolicy = struct {
+	sync.Mutex
+	attempts int
+	maxWait  time.Duration
+}{attempts: 3, maxWait: time.Second}
+
+// SetRetryPolicy sets how often calls into the WSL API are attempted
+// if they fail with an error indicating that the WSL service is busy
+// or not yet available. Calls are attempted at most attempts times,
+// with exponentially increasing delays, and are not retried once the
+// next delay would exceed maxWait since the first attempt. Other
+// errors are returned immediately. The default is 3 attempts within
+// one second; attempts <= 1 disables retries.
+func SetRetryPolicy(attempts int, maxWait time.Duration) {
+	retryPolicy.Lock()
+	defer retryPolicy.Unlock()
+	retryPolicy.attempts, retryPolicy.maxWait = attempts, maxWait
+}
+
+// getRetryPolicy returns the policy set by SetRetryPolicy.
+func getRetryPolicy() (int, time.Duration) {
+	retryPolicy.Lock()
+	defer retryPolicy.Unlock()
+	return retryPolicy.attempts, retryPolicy.maxWait
+}
+
+// initCOM initializes COM for the multithreaded apartment on the
+// current OS thread, which must be locked, and returns a function
+// that undoes the initialization.
+func initCOM() (uninit func(), err error) {
+	switch err := windows.CoInitializeEx(0, windows.COINIT_MULTITHREADED); err {
+	case nil, syscall.Errno(windows.S_FALSE):
+		return windows.CoUninitialize, nil
+	case syscall.Errno(windows.RPC_E_CHANGED_MODE):
+		return func() {}, nil
+	default:
+		return nil, decodeHRESULT(err)
+	}
+}
@@ -0,0 +1,92 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+// setTestRetryPolicy sets the retry policy for the duration of the
+// test.
+func setTestRetryPolicy(t *testing.T, attempts int, maxWait time.Duration) {
+	t.Helper()
+	oldAttempts, oldMaxWait := getRetryPolicy()
+	SetRetryPolicy(attempts, maxWait)
+	t.Cleanup(func() { SetRetryPolicy(oldAttempts, oldMaxWait) })
+}
+
+func TestComCall(t *testing.T) {
+	const (
+		retryLater = syscall.Errno(0x8001010A)
+		invalidArg = syscall.Errno(0x80070057)
+	)
+	setTestRetryPolicy(t, 3, 10*time.Second)
+	for _, c := range []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"success", []error{nil}, 1, false},
+		{"transient then success", []error{retryLater, retryLater, nil}, 3, false},
+		{"non-transient", []error{invalidArg, nil}, 1, true},
+		{"attempts exhausted", []error{retryLater, retryLater, retryLater, nil}, 3, true},
+	} {
+		calls := 0
+		err := comCall(func() error {
+			err := c.errs[calls]
+			calls++
+			return err
+		})
+		if calls != c.wantCalls {
+			t.Errorf("%s: %d calls, want %d", c.name, calls, c.wantCalls)
+		}
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: got error %v", c.name, err)
+		}
+	}
+}
+
+func TestComCallMaxWait(t *testing.T) {
+	// The first retry would be after retryInitialDelay, which
+	// already exceeds maxWait.
+	setTestRetryPolicy(t, 10, retryInitialDelay/2)
+	calls := 0
+	err := comCall(func() error {
+		calls++
+		return syscall.Errno(0x800706BA)
+	})
+	if calls != 1 || err == nil {
+		t.Errorf("%d calls, error %v; want 1 call and an error", calls, err)
+	}
+}
+
+func TestComCallNoRetry(t *testing.T) {
+	setTestRetryPolicy(t, 1, time.Minute)
+	calls := 0
+	comCall(func() error {
+		calls++
+		return syscall.Errno(0x8001010A)
+	})
+	if calls != 1 {
+		t.Errorf("%d calls, want 1", calls)
+	}
+}
@@ -0,0 +1,59 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExitError is returned by functions that run a command to
+// completion if the command exits with a non-zero exit code.
+type ExitError struct {
+	ExitCode uint32
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.ExitCode)
+}
+
+// Result holds the outcome of a command that has been run to
+// completion.
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode uint32
+}
+
+// commandError adds context to an error returned by running command,
+// including the command's output, if any. It returns nil if err is
+// nil.
+func commandError(command string, out []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("%s: %w: %s", command, err, msg)
+	}
+	return fmt.Errorf("%s: %w", command, err)
+}
+
+// shellCommand wraps a POSIX shell script so that it is run by
+// /bin/sh regardless of the default user's login shell.
+func shellCommand(script string) string {
+	return "/bin/sh -c " + ShellQuote(script)
+}
@@ -0,0 +1,151 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// GetDistributionEnvironment returns the default environment of a
+// distribution as a map. Each KEY=VALUE entry is split at the first
+// "=", so values may contain "=" themselves; entries without "=" map
+// to the empty string.
+func GetDistributionEnvironment(name string) (map[string]string, error) {
+	_, _, _, environment, err := GetDistributionConfiguration(name)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(environment))
+	for _, e := range environment {
+		k, v, _ := strings.Cut(e, "=")
+		env[k] = v
+	}
+	return env, nil
+}
+
+// Config holds the settings that can be changed using
+// ConfigureDistribution.
+type Config struct {
+	DefaultUID uint32
+	Flags      DistributionFlags
+}
+
+// ConfigureWith modifies the behavior of a distribution according to
+// cfg, see ConfigureDistribution.
+func ConfigureWith(name string, cfg Config) error {
+	return ConfigureDistribution(name, cfg.DefaultUID, cfg.Flags)
+}
+
+// ConfigureOptions selects the settings changed by
+// ConfigureDistributionOpts. Nil fields are left unchanged.
+type ConfigureOptions struct {
+	DefaultUID *uint32
+	Flags      *DistributionFlags
+}
+
+// ConfigureDistributionOpts modifies the behavior of a distribution
+// like ConfigureDistribution, but keeps the current value of each
+// setting that is nil in opts.
+func ConfigureDistributionOpts(name string, opts ConfigureOptions) error {
+	_, uid, flags, _, err := GetDistributionConfiguration(name)
+	if err != nil {
+		return err
+	}
+	if opts.DefaultUID != nil {
+		uid = *opts.DefaultUID
+	}
+	if opts.Flags != nil {
+		flags = *opts.Flags
+	}
+	return ConfigureDistribution(name, uid, flags)
+}
+
+// SetFlags sets the flags in add and clears the flags in remove for
+// the named distribution, leaving its default UID and all other flags
+// unchanged. Flags present in both add and remove are set.
+func SetFlags(name string, add, remove DistributionFlags) error {
+	_, uid, flags, _, err := GetDistributionConfiguration(name)
+	if err != nil {
+		return err
+	}
+	return ConfigureDistribution(name, uid, flags&^remove|add)
+}
+
+// DistributionConfig holds the configuration of a distribution as
+// returned by GetDistributionConfiguration. In JSON, Flags are
+// represented in the format of DistributionFlags.String.
+type DistributionConfig struct {
+	Version     uint32
+	DefaultUID  uint32
+	Flags       DistributionFlags
+	Environment []string
+}
+
+// distributionConfigJSON is the JSON representation of
+// DistributionConfig.
+type distributionConfigJSON struct {
+	Version     uint32   `json:"version"`
+	DefaultUID  uint32   `json:"defaultUID"`
+	Flags       string   `json:"flags"`
+	Environment []string `json:"environment"`
+}
+
+func (c DistributionConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(distributionConfigJSON{
+		Version:     c.Version,
+		DefaultUID:  c.DefaultUID,
+		Flags:       c.Flags.String(),
+		Environment: c.Environment,
+	})
+}
+
+func (c *DistributionConfig) UnmarshalJSON(data []byte) error {
+	var j distributionConfigJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	flags, err := ParseDistributionFlags(j.Flags)
+	if err != nil {
+		return err
+	}
+	*c = DistributionConfig{
+		Version:     j.Version,
+		DefaultUID:  j.DefaultUID,
+		Flags:       flags,
+		Environment: j.Environment,
+	}
+	return nil
+}
+
+// GetDistributionConfig retrieves the current configuration of a
+// distribution, see GetDistributionConfiguration.
+func GetDistributionConfig(name string) (cfg DistributionConfig, err error) {
+	cfg.Version, cfg.DefaultUID, cfg.Flags, cfg.Environment, err = GetDistributionConfiguration(name)
+	return
+}
+
+// DistributionInfo describes a distribution, see InspectDistribution.
+type DistributionInfo struct {
+	Name        string
+	Registered  bool
+	Version     int // 1 for WSL1, 2 for WSL2
+	DefaultUID  uint32
+	Flags       DistributionFlags
+	Environment []string
+	BasePath    string
+}
@@ -0,0 +1,154 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConfigureDistributionRoundTrip(t *testing.T) {
+	f := useFakeAPI(t, map[string]*DistributionConfig{"test": {Version: 2}})
+	for flags := DISTRIBUTION_FLAGS_NONE; flags <= DISTRIBUTION_FLAGS_ENABLE_INTEROP|
+		DISTRIBUTION_FLAGS_APPEND_NT_PATH|DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING; flags++ {
+		if err := ConfigureDistribution("test", 1000, flags); err != nil {
+			t.Fatal(err)
+		}
+		_, uid, got, _, err := GetDistributionConfiguration("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != flags || uid != 1000 {
+			t.Errorf("configured %v, got %v, uid %d", flags, got, uid)
+		}
+	}
+	// Combining two flags must not produce the third.
+	if err := ConfigureDistribution("test", 0, DISTRIBUTION_FLAGS_APPEND_NT_PATH|DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING); err != nil {
+		t.Fatal(err)
+	}
+	if d := f.distributions["test"]; d.Flags&DISTRIBUTION_FLAGS_ENABLE_INTEROP != 0 {
+		t.Errorf("APPEND_NT_PATH|ENABLE_DRIVE_MOUNTING = %v includes ENABLE_INTEROP", d.Flags)
+	}
+}
+
+func TestConfigureDistributionOpts(t *testing.T) {
+	const initial = DISTRIBUTION_FLAGS_ENABLE_INTEROP | DISTRIBUTION_FLAGS_APPEND_NT_PATH
+	uid := uint32(0)
+	flags := DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING
+	for _, c := range []struct {
+		name      string
+		opts      ConfigureOptions
+		wantUID   uint32
+		wantFlags DistributionFlags
+	}{
+		{"none", ConfigureOptions{}, 1000, initial},
+		{"only flags", ConfigureOptions{Flags: &flags}, 1000, flags},
+		{"only uid", ConfigureOptions{DefaultUID: &uid}, 0, initial},
+		{"both", ConfigureOptions{DefaultUID: &uid, Flags: &flags}, 0, flags},
+	} {
+		f := useFakeAPI(t, map[string]*DistributionConfig{"test": {DefaultUID: 1000, Flags: initial}})
+		if err := ConfigureDistributionOpts("test", c.opts); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if d := f.distributions["test"]; d.DefaultUID != c.wantUID || d.Flags != c.wantFlags {
+			t.Errorf("%s: uid %d, flags %v; want %d, %v", c.name, d.DefaultUID, d.Flags, c.wantUID, c.wantFlags)
+		}
+	}
+
+	useFakeAPI(t, map[string]*DistributionConfig{})
+	if err := ConfigureDistributionOpts("missing", ConfigureOptions{Flags: &flags}); !errors.Is(err, ErrDistributionNotFound) {
+		t.Errorf("got %v, want ErrDistributionNotFound", err)
+	}
+}
+
+func TestSetFlags(t *testing.T) {
+	const (
+		interop = DISTRIBUTION_FLAGS_ENABLE_INTEROP
+		ntPath  = DISTRIBUTION_FLAGS_APPEND_NT_PATH
+		drives  = DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING
+	)
+	for _, c := range []struct {
+		flags, add, remove, want DistributionFlags
+	}{
+		{interop | ntPath | drives, 0, ntPath, interop | drives},
+		{interop, drives, 0, interop | drives},
+		{interop | ntPath, drives, interop, ntPath | drives},
+		// Flags in both add and remove end up set.
+		{0, ntPath, ntPath, ntPath},
+		{interop | ntPath, ntPath, ntPath, interop | ntPath},
+		// Unknown bits are preserved.
+		{0x10 | interop, 0, interop, 0x10},
+	} {
+		f := useFakeAPI(t, map[string]*DistributionConfig{"test": {DefaultUID: 1000, Flags: c.flags}})
+		if err := SetFlags("test", c.add, c.remove); err != nil {
+			t.Fatal(err)
+		}
+		if d := f.distributions["test"]; d.Flags != c.want || d.DefaultUID != 1000 {
+			t.Errorf("SetFlags(%v, add %v, remove %v): flags %v, uid %d; want %v, 1000",
+				c.flags, c.add, c.remove, d.Flags, d.DefaultUID, c.want)
+		}
+	}
+}
+
+func TestSetFlagsNotFound(t *testing.T) {
+	useFakeAPI(t, map[string]*DistributionConfig{})
+	if err := SetFlags("missing", DISTRIBUTION_FLAGS_ENABLE_INTEROP, 0); !errors.Is(err, ErrDistributionNotFound) {
+		t.Errorf("got %v, want ErrDistributionNotFound", err)
+	}
+}
+
+func TestDistributionConfigJSON(t *testing.T) {
+	for _, cfg := range []DistributionConfig{
+		{},
+		{Version: 2, DefaultUID: 1000, Flags: 0x7, Environment: []string{"HOSTTYPE=x86_64", "LANG=C.UTF-8", "X=a=b"}},
+		{Version: 1, Flags: DISTRIBUTION_FLAGS_ENABLE_INTEROP | 0x10, Environment: []string{}},
+		{DefaultUID: 4294967295, Environment: nil},
+		{Environment: []string{""}},
+	} {
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got DistributionConfig
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if !reflect.DeepEqual(got, cfg) {
+			t.Errorf("%s: got %#v, want %#v", data, got, cfg)
+		}
+	}
+}
+
+func TestDistributionConfigJSONFormat(t *testing.T) {
+	data, err := json.Marshal(DistributionConfig{Version: 2, Flags: 0x5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"version":2,"defaultUID":0,"flags":"ENABLE_INTEROP|ENABLE_DRIVE_MOUNTING","environment":null}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var cfg DistributionConfig
+	if err := json.Unmarshal([]byte(`{"version":2}`), &cfg); err != nil || cfg.Flags != 0 {
+		t.Errorf("missing flags: got %v, %v", cfg.Flags, err)
+	}
+	if err := json.Unmarshal([]byte(`{"flags":"BOGUS"}`), &cfg); err == nil {
+		t.Error("invalid flags: no error")
+	}
+}
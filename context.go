@@ -0,0 +1,76 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows"
+)
+
+// LaunchContext launches a WSL process like Launch. If ctx is done
+// before the process exits, the process is terminated using
+// TerminateProcess, and its exit code will be 1 (as with
+// os.Process.Kill). Once the process has exited, cancelling ctx has no
+// effect.
+//
+// The caller remains responsible for closing the returned handle.
+func LaunchContext(ctx context.Context, name, command string, useCwd bool, stdin, stdout, stderr windows.Handle) (process windows.Handle, err error) {
+	if err = ctx.Err(); err != nil {
+		return windows.InvalidHandle, err
+	}
+	if process, err = Launch(name, command, useCwd, stdin, stdout, stderr); err != nil {
+		return
+	}
+	if ctx.Done() == nil {
+		return
+	}
+	if err = watchProcess(ctx, process); err != nil {
+		windows.TerminateProcess(process, 1)
+		windows.CloseHandle(process)
+		process = windows.InvalidHandle
+	}
+	return
+}
+
+// watchProcess terminates process if ctx is done before the process
+// exits. It works on a duplicate of the handle so that the caller may
+// close process at any time.
+func watchProcess(ctx context.Context, process windows.Handle) error {
+	var h windows.Handle
+	self := windows.CurrentProcess()
+	if err := windows.DuplicateHandle(self, process, self, &h, 0, false, windows.DUPLICATE_SAME_ACCESS); err != nil {
+		return err
+	}
+	exited := make(chan struct{})
+	go func() {
+		windows.WaitForSingleObject(h, windows.INFINITE)
+		close(exited)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			windows.TerminateProcess(h, 1)
+			<-exited
+		case <-exited:
+		}
+		windows.CloseHandle(h)
+	}()
+	return nil
+}
@@ -0,0 +1,45 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import "testing"
+
+func TestParseDISMFeatureState(t *testing.T) {
+	const header = "\r\nDeployment Image Servicing and Management tool\r\n" +
+		"Version: 10.0.22621.2792\r\n\r\nImage Version: 10.0.22631.3007\r\n\r\n" +
+		"Feature Information:\r\n\r\nFeature Name : VirtualMachinePlatform\r\n" +
+		"Display Name : Virtual Machine Platform\r\n" +
+		"Description : Enables platform support for virtual machines\r\n" +
+		"Restart Required : Possible\r\n"
+	for _, c := range []struct {
+		out, want string
+	}{
+		{header + "State : Enabled\r\n\r\nCustom Properties:\r\n\r\n(No custom properties found)\r\n", "Enabled"},
+		{header + "State : Disabled\r\n", "Disabled"},
+		{header + "State : Enable Pending\r\n", "Enable Pending"},
+	} {
+		got, err := parseDISMFeatureState([]byte(c.out))
+		if err != nil {
+			t.Errorf("%q: %v", c.want, err)
+		} else if got != c.want {
+			t.Errorf("got %q, want %q", got, c.want)
+		}
+	}
+	if _, err := parseDISMFeatureState([]byte(header)); err == nil {
+		t.Error("missing State: no error")
+	}
+}
@@ -0,0 +1,113 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Distribution refers to a distribution registered with the Windows
+// Subsystem for Linux by its name. Its methods forward to the package
+// functions of the same name.
+type Distribution struct {
+	Name string
+}
+
+// UserDistribution describes a distribution registered for some user,
+// as returned by AllUsersDistributions. Unlike Distribution, it has no
+// methods: the WSL API only acts on the current user's distributions.
+// BasePath is the unexpanded BasePath registry value.
+type UserDistribution struct {
+	SID      string
+	Name     string
+	BasePath string
+}
+
+// validateName checks that name is non-empty, valid UTF-8, and free
+// of control characters and characters that are not allowed in
+// Windows file names, which WSL uses to create the distribution's
+// directory.
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidDistributionName)
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalidDistributionName, name)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || strings.ContainsRune(`\/:*?"<>|`, r) {
+			return fmt.Errorf("%w: %q contains %q", ErrInvalidDistributionName, name, r)
+		}
+	}
+	return nil
+}
+
+// Get returns the named distribution. If no such distribution is
+// registered, ErrDistributionNotFound is returned.
+func Get(name string) (*Distribution, error) {
+	if !IsDistributionRegistered(name) {
+		return nil, fmt.Errorf("%s: %w", name, ErrDistributionNotFound)
+	}
+	return &Distribution{Name: name}, nil
+}
+
+// Configure modifies the behavior of the distribution, see
+// ConfigureDistribution.
+func (d Distribution) Configure(defaultUID uint32, flags DistributionFlags) error {
+	return ConfigureDistribution(d.Name, defaultUID, flags)
+}
+
+// GetConfiguration retrieves the current configuration of the
+// distribution, see GetDistributionConfiguration.
+func (d Distribution) GetConfiguration() (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
+	return GetDistributionConfiguration(d.Name)
+}
+
+// IsRegistered determines if the distribution is registered, see
+// IsDistributionRegistered.
+func (d Distribution) IsRegistered() bool {
+	return IsDistributionRegistered(d.Name)
+}
+
+// Launch launches a WSL process in the context of the distribution,
+// see Launch.
+func (d Distribution) Launch(command string, useCwd bool, stdin, stdout, stderr Handle) (Handle, error) {
+	return Launch(d.Name, command, useCwd, stdin, stdout, stderr)
+}
+
+// LaunchInteractive launches an interactive WSL process in the
+// context of the distribution, see LaunchInteractive.
+func (d Distribution) LaunchInteractive(command string, useCwd bool) (uint32, error) {
+	return LaunchInteractive(d.Name, command, useCwd)
+}
+
+// Unregister unregisters the distribution, see
+// UnregisterDistribution.
+func (d Distribution) Unregister() error {
+	return UnregisterDistribution(d.Name)
+}
+
+// RunResultContext runs command in the context of the distribution,
+// see RunResultContext.
+func (d Distribution) RunResultContext(ctx context.Context, command string, input io.Reader) (Result, error) {
+	return RunResultContext(ctx, d.Name, command, input)
+}
@@ -0,0 +1,82 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// wslapiProcs lists the procedures resolved from wslapi.dll in
+// zsyscall_windows.go.
+var wslapiProcs = []**windows.LazyProc{
+	&procWslConfigureDistribution,
+	&procWslGetDistributionConfiguration,
+	&procWslIsDistributionRegistered,
+	&procWslLaunch,
+	&procWslLaunchInteractive,
+	&procWslRegisterDistribution,
+	&procWslUnregisterDistribution,
+}
+
+// SetWSLAPIPath makes the package use the wslapi.dll at path instead
+// of the one from the system directory. The DLL is loaded and all
+// procedures used by this package are resolved immediately; if that
+// fails, the previous DLL remains in use.
+//
+// SetWSLAPIPath must be called before any other function of this
+// package; it is not safe for concurrent use.
+func SetWSLAPIPath(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	dll := windows.NewLazyDLL(path)
+	if err := dll.Load(); err != nil {
+		return err
+	}
+	procs := make([]*windows.LazyProc, len(wslapiProcs))
+	for i, p := range wslapiProcs {
+		procs[i] = dll.NewProc((*p).Name)
+		if err := procs[i].Find(); err != nil {
+			return err
+		}
+	}
+	modwslapi = dll
+	for i, p := range wslapiProcs {
+		*p = procs[i]
+	}
+	return nil
+}
+
+// Available determines whether wslapi.dll can be loaded and provides
+// the WSL API, without calling into it. If it does not, false is
+// returned along with an error matching ErrNotInstalled that
+// describes the problem.
+func Available() (bool, error) {
+	if err := modwslapi.Load(); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrNotInstalled, err)
+	}
+	if err := procWslLaunch.Find(); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrNotInstalled, err)
+	}
+	return true, nil
+}
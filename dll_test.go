@@ -0,0 +1,99 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+// saveWSLAPI restores the wslapi.dll procedures at the end of the
+// test and returns the current ones.
+func saveWSLAPI(t *testing.T) []*windows.LazyProc {
+	t.Helper()
+	oldDLL := modwslapi
+	old := make([]*windows.LazyProc, len(wslapiProcs))
+	for i, p := range wslapiProcs {
+		old[i] = *p
+	}
+	t.Cleanup(func() {
+		modwslapi = oldDLL
+		for i, p := range wslapiProcs {
+			*p = old[i]
+		}
+	})
+	return old
+}
+
+// systemDLL returns the path of the named DLL in the system directory.
+func systemDLL(t *testing.T, name string) string {
+	t.Helper()
+	dir, err := windows.GetSystemDirectory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, name)
+}
+
+func TestSetWSLAPIPathFailure(t *testing.T) {
+	old := saveWSLAPI(t)
+	oldDLL := modwslapi
+	for _, path := range []string{
+		filepath.Join(t.TempDir(), "missing.dll"),
+		// Loads, but does not provide the WSL API.
+		systemDLL(t, "kernel32.dll"),
+	} {
+		if err := SetWSLAPIPath(path); err == nil {
+			t.Errorf("%s: no error", path)
+		}
+		if modwslapi != oldDLL {
+			t.Errorf("%s: DLL replaced", path)
+		}
+		for i, p := range wslapiProcs {
+			if *p != old[i] {
+				t.Errorf("%s: %s replaced", path, old[i].Name)
+			}
+		}
+	}
+}
+
+func TestSetWSLAPIPath(t *testing.T) {
+	path := systemDLL(t, "wslapi.dll")
+	if _, err := os.Stat(path); err != nil {
+		t.Skip(err)
+	}
+	old := saveWSLAPI(t)
+	if err := SetWSLAPIPath(path); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range wslapiProcs {
+		if *p == old[i] || (*p).Addr() == 0 {
+			t.Errorf("%s not resolved from %s", old[i].Name, path)
+		}
+	}
+	if IsDistributionRegistered("go-wsl-test-not-registered") {
+		t.Error("unregistered distribution reported as registered")
+	}
+	if name := os.Getenv("WSL_TEST_DISTRIBUTION"); name != "" && !IsDistributionRegistered(name) {
+		t.Errorf("%s: not registered through %s", name, path)
+	}
+}
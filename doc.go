@@ -0,0 +1,29 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package wsl implements wrapper functions the Windwos Subsystem For
+// Linux API as documented in
+// https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/_wsl/
+//
+// The API is COM-based. The functions that call into wslapi.dll lock
+// the calling goroutine to its OS thread and initialize COM on that
+// thread for the duration of the call, so they can be used from
+// arbitrary goroutines without preparation. Functions that read the
+// registry or run wsl.exe do not need COM.
+//
+// The package can be imported on other platforms, but its functions
+// return ErrUnsupportedPlatform there.
+package wsl
@@ -0,0 +1,58 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"fmt"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// readEnvironment converts the environment returned by
+// WslGetDistributionConfiguration, an array of count pointers to
+// NUL-terminated UTF-16 strings, into a slice. Each string and the
+// array itself are passed to free once they have been read. env may
+// be nil if there are no variables; nil entries are skipped.
+func readEnvironment(env **uint16, count uint32, free func(unsafe.Pointer)) ([]string, error) {
+	environment := []string{}
+	if env == nil {
+		if count != 0 {
+			return environment, fmt.Errorf("no array for %d environment variables", count)
+		}
+		return environment, nil
+	}
+	for _, p := range unsafe.Slice(env, count) {
+		if p != nil {
+			environment = append(environment, utf16PtrToString(p))
+			free(unsafe.Pointer(p))
+		}
+	}
+	free(unsafe.Pointer(env))
+	return environment, nil
+}
+
+// utf16PtrToString decodes the NUL-terminated UTF-16 string at p.
+func utf16PtrToString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; n++ {
+		ptr = unsafe.Add(ptr, unsafe.Sizeof(*p))
+	}
+	return string(utf16.Decode(unsafe.Slice(p, n)))
+}
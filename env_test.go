@@ -0,0 +1,140 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// fakeEnvironment builds an array of UTF-16 string pointers like the
+// one returned by WslGetDistributionConfiguration. An empty string
+// in vars yields a nil entry.
+func fakeEnvironment(vars []string) []*uint16 {
+	env := make([]*uint16, len(vars))
+	for i, v := range vars {
+		if v != "" {
+			env[i] = &append(utf16.Encode([]rune(v)), 0)[0]
+		}
+	}
+	return env
+}
+
+// freeRecorder records the pointers passed to its free method.
+type freeRecorder struct {
+	mu    sync.Mutex
+	freed map[unsafe.Pointer]int
+}
+
+func (r *freeRecorder) free(p unsafe.Pointer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.freed == nil {
+		r.freed = make(map[unsafe.Pointer]int)
+	}
+	r.freed[p]++
+}
+
+func TestReadEnvironment(t *testing.T) {
+	vars := []string{"PATH=/usr/bin:/bin", "", "LANG=de_DE.UTF-8", "GREETING=grüß dich 🙂"}
+	env := fakeEnvironment(vars)
+	var r freeRecorder
+	got, err := readEnvironment(&env[0], uint32(len(env)), r.free)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PATH=/usr/bin:/bin", "LANG=de_DE.UTF-8", "GREETING=grüß dich 🙂"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(r.freed) != len(want)+1 {
+		t.Errorf("%d pointers freed, want %d", len(r.freed), len(want)+1)
+	}
+	if r.freed[unsafe.Pointer(&env[0])] != 1 {
+		t.Error("array not freed exactly once")
+	}
+	for i, p := range env {
+		if p != nil && r.freed[unsafe.Pointer(p)] != 1 {
+			t.Errorf("string %d freed %d times", i, r.freed[unsafe.Pointer(p)])
+		}
+	}
+}
+
+func TestReadEnvironmentEmpty(t *testing.T) {
+	var r freeRecorder
+	got, err := readEnvironment(nil, 0, r.free)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("nil array: got %q, %v; want empty slice", got, err)
+	}
+	if len(r.freed) != 0 {
+		t.Errorf("nil array: %d pointers freed", len(r.freed))
+	}
+
+	if _, err := readEnvironment(nil, 2, r.free); err == nil {
+		t.Error("nil array with count 2 accepted")
+	}
+
+	// The API may return an allocated array even if there are no
+	// variables; it must still be freed.
+	env := fakeEnvironment([]string{"unused"})
+	got, err = readEnvironment(&env[0], 0, r.free)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("zero count: got %q, %v; want empty slice", got, err)
+	}
+	if len(r.freed) != 1 || r.freed[unsafe.Pointer(&env[0])] != 1 {
+		t.Errorf("zero count: freed %v, want only the array", r.freed)
+	}
+}
+
+func TestReadEnvironmentConcurrent(t *testing.T) {
+	var r freeRecorder
+	var wg sync.WaitGroup
+	envs := make([][]*uint16, 8)
+	for i := range envs {
+		envs[i] = fakeEnvironment([]string{"A=1", "B=2", "", "C=3"})
+		wg.Add(1)
+		go func(env []*uint16) {
+			defer wg.Done()
+			if got, err := readEnvironment(&env[0], uint32(len(env)), r.free); err != nil || len(got) != 3 {
+				t.Errorf("got %q, %v", got, err)
+			}
+		}(envs[i])
+	}
+	wg.Wait()
+	if want := len(envs) * 4; len(r.freed) != want {
+		t.Errorf("%d pointers freed, want %d", len(r.freed), want)
+	}
+	for p, n := range r.freed {
+		if n != 1 {
+			t.Errorf("%p freed %d times", p, n)
+		}
+	}
+}
+
+func TestUTF16PtrToString(t *testing.T) {
+	for _, s := range []string{"", "a", "Grüße", "🙂 x"} {
+		if got := utf16PtrToString(&append(utf16.Encode([]rune(s)), 0)[0]); got != s {
+			t.Errorf("got %q, want %q", got, s)
+		}
+	}
+	if got := utf16PtrToString(nil); got != "" {
+		t.Errorf("nil: got %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var (
+	// ErrDistributionNotFound is returned if no distribution with
+	// the given name is registered.
+	ErrDistributionNotFound = errors.New("distribution not found")
+	// ErrInvalidDistributionName is returned if a distribution
+	// name is empty or contains characters that WSL does not
+	// accept.
+	ErrInvalidDistributionName = errors.New("invalid distribution name")
+	// ErrNoDefaultDistribution is returned by
+	// GetDefaultDistribution if no default distribution is set.
+	ErrNoDefaultDistribution = errors.New("no default distribution")
+	// ErrUserNotFound is returned by LookupUID if the user does not
+	// exist inside the distribution.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrTimeout is returned if a process has not exited within
+	// the given time.
+	ErrTimeout = errors.New("timeout waiting for process")
+	// ErrAccessDenied is returned if the WSL API denies access.
+	ErrAccessDenied = errors.New("access denied")
+	// ErrAlreadyExists is returned if a distribution with the
+	// given name is already registered.
+	ErrAlreadyExists = errors.New("distribution already exists")
+	// ErrInvalidArgument is returned if the WSL API rejects an
+	// argument.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrNotInstalled is returned if the Windows Subsystem for
+	// Linux optional component is not enabled.
+	ErrNotInstalled = errors.New("Windows Subsystem for Linux is not installed")
+	// ErrUpdateRequired is returned if the Windows Subsystem for
+	// Linux needs to be updated.
+	ErrUpdateRequired = errors.New("Windows Subsystem for Linux requires an update")
+	// ErrProcessClosed is returned by ProcessHandle.Wait after the
+	// handle has been closed without waiting for the process.
+	ErrProcessClosed = errors.New("process handle closed")
+	// ErrUsbipdNotInstalled is returned if usbipd-win cannot be
+	// found in the PATH.
+	ErrUsbipdNotInstalled = errors.New("usbipd-win is not installed")
+	// ErrUnsupportedPlatform is returned by all functions that
+	// access WSL on platforms other than Windows.
+	ErrUnsupportedPlatform = errors.New("not supported on this platform")
+)
+
+// maxLaunchErrorCommand is the number of bytes of the command that
+// are included in the message of a LaunchError.
+const maxLaunchErrorCommand = 64
+
+// LaunchError is returned by Launch and LaunchInteractive if a
+// process could not be started.
+type LaunchError struct {
+	Distribution string
+	Command      string
+	Err          error
+}
+
+func (e *LaunchError) Error() string {
+	command := e.Command
+	if len(command) > maxLaunchErrorCommand {
+		// Cut at a rune boundary.
+		n := maxLaunchErrorCommand
+		for n > 0 && !utf8.RuneStart(command[n]) {
+			n--
+		}
+		command = command[:n] + "..."
+	}
+	return fmt.Sprintf("launching %q in %s: %v", command, e.Distribution, e.Err)
+}
+
+func (e *LaunchError) Unwrap() error { return e.Err }
+
+// launchError wraps err in a LaunchError. Since the WSL API does not
+// reliably report unknown distributions as such, the error also
+// matches ErrDistributionNotFound if name is not registered.
+//
+// launchError is called from syscallAPI, possibly on a Serializer's
+// thread, so it must not go through Default.
+func launchError(name, command string, err error) error {
+	if !errors.Is(err, ErrDistributionNotFound) && !errors.Is(err, ErrInvalidDistributionName) &&
+		!(syscallAPI{}).IsRegistered(name) {
+		err = fmt.Errorf("%w: %v", ErrDistributionNotFound, err)
+	}
+	return &LaunchError{Distribution: name, Command: command, Err: err}
+}
@@ -0,0 +1,61 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLaunchErrorMessage(t *testing.T) {
+	long := strings.Repeat("x", maxLaunchErrorCommand)
+	for _, c := range []struct {
+		name, command, want string
+	}{
+		{"short", "echo hi", `launching "echo hi" in Ubuntu: distribution not found`},
+		{"at limit", long, `launching "` + long + `" in Ubuntu: distribution not found`},
+		{"truncated", long + "yz", `launching "` + long + `..." in Ubuntu: distribution not found`},
+		// "ü" takes two bytes, the second of which is past the limit.
+		{"rune boundary", long[1:] + "üz", `launching "` + long[1:] + `..." in Ubuntu: distribution not found`},
+		{"multibyte at limit", long[2:] + "üz", `launching "` + long[2:] + `ü..." in Ubuntu: distribution not found`},
+	} {
+		err := &LaunchError{Distribution: "Ubuntu", Command: c.command, Err: ErrDistributionNotFound}
+		if got := err.Error(); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+		if !utf8.ValidString(err.Error()) {
+			t.Errorf("%s: message is not valid UTF-8", c.name)
+		}
+		if !errors.Is(err, ErrDistributionNotFound) {
+			t.Errorf("%s: does not match ErrDistributionNotFound", c.name)
+		}
+	}
+}
+
+func TestLaunchErrorNotRegistered(t *testing.T) {
+	err := launchError("go-wsl-test-not-registered", "true", errors.New("WslLaunch failed"))
+	var launchErr *LaunchError
+	if !errors.As(err, &launchErr) || !errors.Is(err, ErrDistributionNotFound) {
+		t.Fatalf("got %v, want a LaunchError matching ErrDistributionNotFound", err)
+	}
+	want := `launching "true" in go-wsl-test-not-registered: distribution not found: WslLaunch failed`
+	if got := err.Error(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import "fmt"
+
+// Feature is a WSL feature that is only available starting with a
+// particular Windows build.
+type Feature string
+
+const (
+	FeatureWSL2               Feature = "WSL2"
+	FeatureImportExport       Feature = "import/export"
+	FeatureCd                 Feature = "wsl.exe --cd"
+	FeatureSystemd            Feature = "systemd"
+	FeatureMirroredNetworking Feature = "mirrored networking"
+)
+
+// featureBuilds maps features to the first Windows build that
+// supports them.
+var featureBuilds = map[Feature]int{
+	FeatureImportExport:       17763,
+	FeatureWSL2:               19041,
+	FeatureCd:                 19041,
+	FeatureSystemd:            19044,
+	FeatureMirroredNetworking: 22621,
+}
+
+// ErrFeatureRequiresBuild is returned by RequireFeature if the
+// running Windows build is older than the given build.
+type ErrFeatureRequiresBuild int
+
+func (e ErrFeatureRequiresBuild) Error() string {
+	return fmt.Sprintf("feature requires Windows build %d or later", int(e))
+}
+
+// RequireFeature returns an ErrFeatureRequiresBuild if feature is not
+// supported by the running Windows build.
+func RequireFeature(feature Feature) error {
+	build, err := WindowsBuild()
+	if err != nil {
+		return err
+	}
+	return checkFeature(feature, build)
+}
+
+// checkFeature checks whether feature is supported by build.
+func checkFeature(feature Feature, build int) error {
+	required, ok := featureBuilds[feature]
+	if !ok {
+		return fmt.Errorf("unknown feature %q", feature)
+	}
+	if build < required {
+		return ErrFeatureRequiresBuild(required)
+	}
+	return nil
+}
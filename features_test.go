@@ -0,0 +1,53 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckFeature(t *testing.T) {
+	for feature, build := range featureBuilds {
+		if err := checkFeature(feature, build-1); !errors.Is(err, ErrFeatureRequiresBuild(build)) {
+			t.Errorf("%s on build %d: got %v, want ErrFeatureRequiresBuild(%d)", feature, build-1, err, build)
+		} else {
+			var required ErrFeatureRequiresBuild
+			if !errors.As(err, &required) || int(required) != build {
+				t.Errorf("%s on build %d: errors.As gave %d", feature, build-1, required)
+			}
+		}
+		for _, b := range []int{build, build + 1} {
+			if err := checkFeature(feature, b); err != nil {
+				t.Errorf("%s on build %d: %v", feature, b, err)
+			}
+		}
+	}
+	if err := checkFeature("teleportation", 99999); err == nil {
+		t.Error("unknown feature accepted")
+	}
+}
+
+func TestErrFeatureRequiresBuild(t *testing.T) {
+	err := ErrFeatureRequiresBuild(22621)
+	if got, want := err.Error(), "feature requires Windows build 22621 or later"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if errors.Is(err, ErrFeatureRequiresBuild(19041)) {
+		t.Error("errors for different builds match")
+	}
+}
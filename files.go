@@ -0,0 +1,61 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaitForFile waits until path exists inside the distribution,
+// checking every poll interval, or until ctx is done, in which case
+// ctx.Err() is returned. A check that hangs, e.g. on an unresponsive
+// network file system, is cancelled along with ctx.
+func (d Distribution) WaitForFile(ctx context.Context, path string, poll time.Duration) error {
+	return waitFor(ctx, poll, func(ctx context.Context) (bool, error) {
+		_, err := LaunchCombinedOutputContext(ctx, d.Name, "test -e "+ShellQuote(path), false)
+		var exitErr *ExitError
+		if err == nil {
+			return true, nil
+		} else if errors.As(err, &exitErr) && exitErr.ExitCode == 1 {
+			return false, nil
+		}
+		return false, err
+	})
+}
+
+// waitFor calls probe every poll interval until it reports success or
+// fails, or until ctx is done.
+func waitFor(ctx context.Context, poll time.Duration, probe func(context.Context) (bool, error)) error {
+	if poll <= 0 {
+		return fmt.Errorf("invalid poll interval %v", poll)
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		if ok, err := probe(ctx); ok || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitFor(t *testing.T) {
+	calls := 0
+	err := waitFor(context.Background(), time.Millisecond, func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("got %v after %d calls, want success after 3", err, calls)
+	}
+}
+
+func TestWaitForError(t *testing.T) {
+	probeErr := errors.New("probe failed")
+	calls := 0
+	err := waitFor(context.Background(), time.Millisecond, func(context.Context) (bool, error) {
+		calls++
+		return false, probeErr
+	})
+	if err != probeErr || calls != 1 {
+		t.Errorf("got %v after %d calls, want probe error after 1", err, calls)
+	}
+}
+
+func TestWaitForCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := waitFor(ctx, time.Millisecond, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestWaitForHungProbe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// The probe only returns once its context is done.
+	err := waitFor(ctx, time.Hour, func(ctx context.Context) (bool, error) {
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestWaitForInvalidPoll(t *testing.T) {
+	for _, poll := range []time.Duration{0, -time.Second} {
+		err := waitFor(context.Background(), poll, func(context.Context) (bool, error) {
+			t.Fatal("probe called")
+			return false, nil
+		})
+		if err == nil {
+			t.Errorf("poll %v: no error", poll)
+		}
+	}
+}
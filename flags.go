@@ -0,0 +1,102 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DistributionFlags is a bit mask that specifies the behavior of a
+// distribution, see WSL_DISTRIBUTION_FLAGS in wslapi.h.
+type DistributionFlags uint32
+
+const (
+	DISTRIBUTION_FLAGS_NONE                  DistributionFlags = 0x0
+	DISTRIBUTION_FLAGS_ENABLE_INTEROP        DistributionFlags = 0x1
+	DISTRIBUTION_FLAGS_APPEND_NT_PATH        DistributionFlags = 0x2
+	DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING DistributionFlags = 0x4
+)
+
+// flagNames lists the names of the known flags in the order in which
+// they are rendered by String.
+var flagNames = []struct {
+	flag DistributionFlags
+	name string
+}{
+	{DISTRIBUTION_FLAGS_ENABLE_INTEROP, "ENABLE_INTEROP"},
+	{DISTRIBUTION_FLAGS_APPEND_NT_PATH, "APPEND_NT_PATH"},
+	{DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING, "ENABLE_DRIVE_MOUNTING"},
+}
+
+// String renders the set flags as pipe-separated names, e.g.
+// "ENABLE_INTEROP|APPEND_NT_PATH". Unknown bits are appended as a
+// hexadecimal number; no flags at all are rendered as "NONE".
+func (f DistributionFlags) String() string {
+	if f == DISTRIBUTION_FLAGS_NONE {
+		return "NONE"
+	}
+	var names []string
+	for _, n := range flagNames {
+		if f&n.flag != 0 {
+			names = append(names, n.name)
+			f &^= n.flag
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseDistributionFlags parses flags in the format produced by
+// DistributionFlags.String. An empty string is parsed as NONE.
+func ParseDistributionFlags(s string) (DistributionFlags, error) {
+	var flags DistributionFlags
+	if strings.TrimSpace(s) == "" {
+		return flags, nil
+	}
+	for _, name := range strings.Split(s, "|") {
+		name = strings.TrimSpace(name)
+		if name == "NONE" {
+			continue
+		}
+		if flag, ok := parseFlagName(name); ok {
+			flags |= flag
+			continue
+		}
+		if strings.HasPrefix(name, "0x") {
+			if v, err := strconv.ParseUint(name[2:], 16, 32); err == nil {
+				flags |= DistributionFlags(v)
+				continue
+			}
+		}
+		return 0, fmt.Errorf("invalid distribution flag %q", name)
+	}
+	return flags, nil
+}
+
+// parseFlagName returns the flag with the given name.
+func parseFlagName(name string) (DistributionFlags, bool) {
+	for _, n := range flagNames {
+		if n.name == name {
+			return n.flag, true
+		}
+	}
+	return 0, false
+}
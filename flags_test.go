@@ -0,0 +1,61 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import "testing"
+
+func TestDistributionFlagsString(t *testing.T) {
+	for _, c := range []struct {
+		flags DistributionFlags
+		want  string
+	}{
+		{DISTRIBUTION_FLAGS_NONE, "NONE"},
+		{DISTRIBUTION_FLAGS_ENABLE_INTEROP, "ENABLE_INTEROP"},
+		{DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING | DISTRIBUTION_FLAGS_ENABLE_INTEROP,
+			"ENABLE_INTEROP|ENABLE_DRIVE_MOUNTING"},
+		{0x7, "ENABLE_INTEROP|APPEND_NT_PATH|ENABLE_DRIVE_MOUNTING"},
+		{0x11, "ENABLE_INTEROP|0x10"},
+		{0x18, "0x18"},
+	} {
+		if got := c.flags.String(); got != c.want {
+			t.Errorf("%#x.String() = %q, want %q", uint32(c.flags), got, c.want)
+		}
+		if got, err := ParseDistributionFlags(c.want); err != nil || got != c.flags {
+			t.Errorf("ParseDistributionFlags(%q) = %#x, %v, want %#x", c.want, uint32(got), err, uint32(c.flags))
+		}
+	}
+}
+
+func TestParseDistributionFlags(t *testing.T) {
+	for s, want := range map[string]DistributionFlags{
+		"":                                0,
+		" ":                               0,
+		"NONE|NONE":                       0,
+		"APPEND_NT_PATH | ENABLE_INTEROP": 0x3,
+		"ENABLE_INTEROP|ENABLE_INTEROP":   0x1,
+		"0x8|0x1":                         0x9,
+	} {
+		if got, err := ParseDistributionFlags(s); err != nil || got != want {
+			t.Errorf("ParseDistributionFlags(%q) = %#x, %v, want %#x", s, uint32(got), err, uint32(want))
+		}
+	}
+	for _, s := range []string{"enable_interop", "ENABLE_INTEROP|", "BOGUS", "0x", "0x1ffffffff", "8"} {
+		if got, err := ParseDistributionFlags(s); err == nil {
+			t.Errorf("ParseDistributionFlags(%q) = %#x, want error", s, uint32(got))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// hresults maps known HRESULT values returned by the WSL API to
+// sentinel errors.
+var hresults = map[syscall.Errno]error{
+	0x80070005: ErrAccessDenied,         // E_ACCESSDENIED
+	0x80070057: ErrInvalidArgument,      // E_INVALIDARG
+	0x800700B7: ErrAlreadyExists,        // HRESULT_FROM_WIN32(ERROR_ALREADY_EXISTS)
+	0x8007019E: ErrNotInstalled,         // HRESULT_FROM_WIN32(ERROR_LINUX_SUBSYSTEM_NOT_PRESENT)
+	0x800701BC: ErrUpdateRequired,       // HRESULT_FROM_WIN32(ERROR_LINUX_SUBSYSTEM_UPDATE_REQUIRED)
+	0x80070490: ErrDistributionNotFound, // HRESULT_FROM_WIN32(ERROR_NOT_FOUND)
+}
+
+// hresultError is an HRESULT returned by a failed WSL API call. It
+// matches the corresponding sentinel error, if one is known, using
+// errors.Is; errors.Unwrap returns the HRESULT as syscall.Errno.
+type hresultError struct {
+	hr       syscall.Errno
+	sentinel error
+}
+
+func (e *hresultError) Error() string {
+	if e.sentinel != nil {
+		return fmt.Sprintf("%v (HRESULT 0x%08X)", e.sentinel, uint32(e.hr))
+	}
+	return fmt.Sprintf("HRESULT 0x%08X: %v", uint32(e.hr), e.hr)
+}
+
+func (e *hresultError) Unwrap() error { return e.hr }
+
+func (e *hresultError) Is(target error) bool {
+	return e.sentinel != nil && target == e.sentinel
+}
+
+// decodeHRESULT wraps HRESULT errors returned by the WSL API in
+// hresultError. Other errors are returned unchanged.
+func decodeHRESULT(err error) error {
+	hr, ok := err.(syscall.Errno)
+	if !ok || hr&0x80000000 == 0 {
+		return err
+	}
+	return &hresultError{hr: hr, sentinel: hresults[hr]}
+}
@@ -0,0 +1,90 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// LaunchInteractiveTimeout launches a WSL process connected to the
+// current process' standard handles and waits at most timeout for it
+// to exit. If it does not exit in time, it is terminated and
+// ErrTimeout is returned.
+//
+// WslLaunchInteractive does not return a process handle that could be
+// waited on, so this uses WslLaunch with the standard handles of the
+// current process instead. The difference is that the process is not
+// attached to the console as by WslLaunchInteractive: if the standard
+// handles refer to a console, it works like an interactive launch,
+// but Ctrl-C handling and terminal size changes are not forwarded.
+func LaunchInteractiveTimeout(name, command string, useCwd bool, timeout time.Duration) (uint32, error) {
+	stdin, stdout, stderr, err := stdHandles()
+	if err != nil {
+		return 0, err
+	}
+	process, err := LaunchProcess(name, command, useCwd, stdin, stdout, stderr)
+	if err != nil {
+		return 0, err
+	}
+	// Clamp the timeout to what WaitForSingleObject can express
+	// without treating it as INFINITE.
+	ms := uint32(windows.INFINITE - 1)
+	if timeout <= 0 {
+		ms = 0
+	} else if timeout < time.Duration(ms)*time.Millisecond {
+		ms = uint32(timeout / time.Millisecond)
+	}
+	if ev, err := windows.WaitForSingleObject(process.Handle(), ms); err != nil {
+		process.Wait()
+		return 0, err
+	} else if ev == uint32(windows.WAIT_TIMEOUT) {
+		windows.TerminateProcess(process.Handle(), 1)
+		process.Wait()
+		return 0, ErrTimeout
+	}
+	return process.Wait()
+}
+
+// LaunchConsole launches a WSL process like Launch, connected to the
+// standard handles of the current process. Unlike LaunchInteractive,
+// it returns without waiting for the process; the caller is
+// responsible for waiting for and closing the returned handle. As
+// with LaunchInteractiveTimeout, the process is not attached to the
+// console itself.
+func LaunchConsole(name, command string, useCwd bool) (windows.Handle, error) {
+	stdin, stdout, stderr, err := stdHandles()
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	return Launch(name, command, useCwd, stdin, stdout, stderr)
+}
+
+// stdHandles returns the standard handles of the current process.
+func stdHandles() (stdin, stdout, stderr windows.Handle, err error) {
+	if stdin, err = windows.GetStdHandle(windows.STD_INPUT_HANDLE); err != nil {
+		return
+	}
+	if stdout, err = windows.GetStdHandle(windows.STD_OUTPUT_HANDLE); err != nil {
+		return
+	}
+	stderr, err = windows.GetStdHandle(windows.STD_ERROR_HANDLE)
+	return
+}
@@ -0,0 +1,64 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// LaunchLatency runs true in the distribution samples times and
+// returns the median time a launch takes. An additional launch is
+// performed before the measurement so that the result does not
+// include starting the distribution (or, for WSL2, its VM).
+func (d Distribution) LaunchLatency(samples int) (time.Duration, error) {
+	return launchLatency(samples, func() error {
+		_, err := LaunchCombinedOutput(d.Name, "true", false)
+		return err
+	}, time.Now)
+}
+
+// launchLatency implements LaunchLatency for the given launch
+// function, taking the time from now.
+func launchLatency(samples int, launch func() error, now func() time.Time) (time.Duration, error) {
+	if samples < 1 {
+		return 0, errors.New("LaunchLatency: samples must be positive")
+	}
+	durations := make([]time.Duration, 0, samples)
+	for i := 0; i <= samples; i++ {
+		start := now()
+		if err := launch(); err != nil {
+			return 0, err
+		}
+		if i > 0 {
+			durations = append(durations, now().Sub(start))
+		}
+	}
+	return median(durations), nil
+}
+
+// median returns the median of durations, which must not be empty.
+// durations is sorted in place.
+func median(durations []time.Duration) time.Duration {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	n := len(durations)
+	if n%2 == 1 {
+		return durations[n/2]
+	}
+	return (durations[n/2-1] + durations[n/2]) / 2
+}
@@ -0,0 +1,105 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMedian(t *testing.T) {
+	for _, c := range []struct {
+		in   []time.Duration
+		want time.Duration
+	}{
+		{[]time.Duration{5}, 5},
+		{[]time.Duration{3, 1, 2}, 2},
+		{[]time.Duration{9, 1, 5, 7, 3}, 5},
+		{[]time.Duration{4, 1}, 2},
+		{[]time.Duration{8, 2, 6, 4}, 5},
+		{[]time.Duration{1, 1, 1, 100}, 1},
+	} {
+		in := append([]time.Duration(nil), c.in...)
+		if got := median(in); got != c.want {
+			t.Errorf("median(%v) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+// fakeLauncher simulates launches taking the given durations on a fake
+// clock.
+type fakeLauncher struct {
+	clock     time.Time
+	latencies []time.Duration
+	launches  int
+	err       error
+}
+
+func (f *fakeLauncher) now() time.Time { return f.clock }
+
+func (f *fakeLauncher) launch() error {
+	if f.err != nil {
+		return f.err
+	}
+	f.clock = f.clock.Add(f.latencies[f.launches])
+	f.launches++
+	return nil
+}
+
+func TestLaunchLatency(t *testing.T) {
+	for _, c := range []struct {
+		latencies []time.Duration
+		want      time.Duration
+	}{
+		// The slow cold start is not part of the result.
+		{[]time.Duration{10 * time.Second, 30 * time.Millisecond}, 30 * time.Millisecond},
+		{[]time.Duration{10 * time.Second, 30, 10, 20}, 20},
+		{[]time.Duration{10 * time.Second, 40, 10, 30, 20}, 25},
+		// The first launch is discarded even if it is the fastest.
+		{[]time.Duration{1, 5, 6, 7}, 6},
+	} {
+		f := &fakeLauncher{latencies: c.latencies}
+		got, err := launchLatency(len(c.latencies)-1, f.launch, f.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("latencies %v: got %v, want %v", c.latencies, got, c.want)
+		}
+		if f.launches != len(c.latencies) {
+			t.Errorf("latencies %v: %d launches, want %d", c.latencies, f.launches, len(c.latencies))
+		}
+	}
+}
+
+func TestLaunchLatencyErrors(t *testing.T) {
+	f := &fakeLauncher{}
+	for _, samples := range []int{0, -1} {
+		if _, err := launchLatency(samples, f.launch, f.now); err == nil {
+			t.Errorf("%d samples accepted", samples)
+		}
+	}
+	if f.launches != 0 {
+		t.Errorf("%d launches for invalid sample counts", f.launches)
+	}
+	errLaunch := errors.New("launch failed")
+	f.err = errLaunch
+	if _, err := launchLatency(3, f.launch, f.now); !errors.Is(err, errLaunch) {
+		t.Errorf("got %v, want %v", err, errLaunch)
+	}
+}
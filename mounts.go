@@ -0,0 +1,124 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mount describes a mounted filesystem as listed in /proc/mounts.
+type Mount struct {
+	Source  string
+	Target  string
+	FSType  string
+	Options []string
+}
+
+// Mounts returns the filesystems mounted inside the distribution.
+func (d Distribution) Mounts() ([]Mount, error) {
+	out, err := LaunchCombinedOutput(d.Name, "cat /proc/mounts", false)
+	if err != nil {
+		return nil, fmt.Errorf("reading /proc/mounts: %w", err)
+	}
+	return parseMounts(string(out)), nil
+}
+
+// Unmount unmounts the filesystem mounted at target inside the
+// distribution. The umount command is run as the distribution's
+// default user, which needs to be privileged to do so.
+func (d Distribution) Unmount(target string) error {
+	out, err := LaunchCombinedOutput(d.Name, unmountCommand(target), false)
+	return commandError("umount "+target, out, err)
+}
+
+// unmountCommand returns the command that unmounts target.
+func unmountCommand(target string) string {
+	return "umount -- " + ShellQuote(target)
+}
+
+// IsRootReadOnly determines whether the root filesystem of the
+// distribution is mounted read-only, as happens when the kernel
+// detects errors on a WSL2 distribution's disk image.
+func (d Distribution) IsRootReadOnly() (bool, error) {
+	mounts, err := d.Mounts()
+	if err != nil {
+		return false, err
+	}
+	return rootReadOnly(mounts)
+}
+
+// rootReadOnly checks the options of the last (i.e. topmost) mount
+// on / for the ro flag.
+func rootReadOnly(mounts []Mount) (bool, error) {
+	var root *Mount
+	for i := range mounts {
+		if mounts[i].Target == "/" {
+			root = &mounts[i]
+		}
+	}
+	if root == nil {
+		return false, errors.New("no root filesystem in /proc/mounts")
+	}
+	for _, o := range root.Options {
+		if o == "ro" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseMounts parses the contents of /proc/mounts. Lines with fewer
+// than four fields are skipped.
+func parseMounts(s string) (mounts []Mount) {
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		mounts = append(mounts, Mount{
+			Source:  unescapeMountField(fields[0]),
+			Target:  unescapeMountField(fields[1]),
+			FSType:  fields[2],
+			Options: strings.Split(fields[3], ","),
+		})
+	}
+	return
+}
+
+// unescapeMountField decodes the octal escapes (such as \040 for a
+// space) that the kernel uses for whitespace and backslashes in
+// /proc/mounts.
+func unescapeMountField(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"reflect"
+	"testing"
+)
+
+// WSL2 /proc/mounts excerpts with the root filesystem mounted
+// read-write and, after disk errors, read-only.
+const (
+	procMountsRW = `none /mnt/wsl tmpfs rw,relatime 0 0
+/dev/sdc / ext4 rw,relatime,discard,errors=remount-ro,data=ordered 0 0
+none /mnt/wslg tmpfs rw,relatime 0 0
+C:\134 /mnt/c 9p rw,noatime,dirsync,aname=drvfs;path=C:\;uid=1000;gid=1000 0 0
+`
+	procMountsRO = `none /mnt/wsl tmpfs rw,relatime 0 0
+/dev/sdc / ext4 ro,relatime,discard,errors=remount-ro,data=ordered 0 0
+none /mnt/wslg tmpfs rw,relatime 0 0
+`
+)
+
+func TestRootReadOnly(t *testing.T) {
+	for _, c := range []struct {
+		name, mounts string
+		want         bool
+	}{
+		{"rw", procMountsRW, false},
+		{"ro", procMountsRO, true},
+		// The topmost mount on / counts.
+		{"ro below rw", "rootfs / rootfs ro 0 0\n/dev/sdc / ext4 rw,relatime 0 0\n", false},
+		{"rw below ro", "rootfs / rootfs rw 0 0\n/dev/sdc / ext4 ro,relatime 0 0\n", true},
+		// Options are matched exactly.
+		{"errors=remount-ro", "/dev/sdc / ext4 rw,errors=remount-ro 0 0\n", false},
+	} {
+		got, err := rootReadOnly(parseMounts(c.mounts))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		} else if got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+	if _, err := rootReadOnly(parseMounts("none /mnt/wsl tmpfs rw 0 0\n")); err == nil {
+		t.Error("no root mount: no error")
+	}
+}
+
+func TestUnescapeMountField(t *testing.T) {
+	for s, want := range map[string]string{
+		"/mnt/c":             "/mnt/c",
+		`/mnt/my\040disk`:    "/mnt/my disk",
+		`C:\134`:             `C:\`,
+		`tab\011end`:         "tab\tend",
+		`\040\040`:           "  ",
+		`trailing\`:          `trailing\`,
+		`short\04`:           `short\04`,
+		`not\999octal`:       `not\999octal`,
+		`\134\134server\040`: `\\server `,
+	} {
+		if got := unescapeMountField(s); got != want {
+			t.Errorf("unescapeMountField(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestParseMounts(t *testing.T) {
+	got := parseMounts(procMountsRW + "\nshort line\n")
+	want := []Mount{
+		{Source: "none", Target: "/mnt/wsl", FSType: "tmpfs", Options: []string{"rw", "relatime"}},
+		{Source: "/dev/sdc", Target: "/", FSType: "ext4", Options: []string{"rw", "relatime", "discard", "errors=remount-ro", "data=ordered"}},
+		{Source: "none", Target: "/mnt/wslg", FSType: "tmpfs", Options: []string{"rw", "relatime"}},
+		{Source: `C:\`, Target: "/mnt/c", FSType: "9p", Options: []string{"rw", "noatime", "dirsync", "aname=drvfs;path=C:\\;uid=1000;gid=1000"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if got := parseMounts(`/dev/sdd /mnt/my\040disk ext4 rw 0 0`); len(got) != 1 || got[0].Target != "/mnt/my disk" {
+		t.Errorf("escaped target: got %+v", got)
+	}
+}
+
+func TestUnmountCommand(t *testing.T) {
+	for target, want := range map[string]string{
+		"/mnt/data":      "umount -- '/mnt/data'",
+		"/mnt/my disk":   "umount -- '/mnt/my disk'",
+		"/mnt/it's":      `umount -- '/mnt/it'\''s'`,
+		"-f":             "umount -- '-f'",
+		"/mnt/$(reboot)": "umount -- '/mnt/$(reboot)'",
+	} {
+		if got := unmountCommand(target); got != want {
+			t.Errorf("unmountCommand(%q) = %s, want %s", target, got, want)
+		}
+	}
+}
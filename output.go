@@ -0,0 +1,161 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"context"
+	"io"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// LaunchCombinedOutput runs command in the context of a particular
+// distribution and returns its combined standard output and standard
+// error. Standard input is connected to the null device. If the
+// command exits with a non-zero exit code, the output is returned
+// along with an *ExitError.
+func LaunchCombinedOutput(name, command string, useCwd bool) ([]byte, error) {
+	return LaunchCombinedOutputContext(context.Background(), name, command, useCwd)
+}
+
+// LaunchCombinedOutputContext runs command like LaunchCombinedOutput.
+// If ctx is done before the command exits, the process is terminated
+// as with LaunchContext, reading its output is stopped, and the output
+// captured until then is returned along with ctx.Err().
+func LaunchCombinedOutputContext(ctx context.Context, name, command string, useCwd bool) (out []byte, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var stdin *os.File
+	if stdin, err = os.Open(os.DevNull); err != nil {
+		return
+	}
+	defer stdin.Close()
+	var r, w *os.File
+	if r, w, err = pipe(false); err != nil {
+		return
+	}
+	defer r.Close()
+	process, err := LaunchProcess(name, command, useCwd,
+		windows.Handle(stdin.Fd()), windows.Handle(w.Fd()), windows.Handle(w.Fd()))
+	// The WSL process has its own copy of the write end now; close
+	// ours so that reading ends when the process is done.
+	w.Close()
+	if err != nil {
+		return
+	}
+	if ctx.Done() != nil {
+		if err = watchProcess(ctx, process.Handle()); err != nil {
+			windows.TerminateProcess(process.Handle(), 1)
+		}
+	}
+	// Keep draining the pipe while waiting for the process so that
+	// it does not block on a full pipe buffer.
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		out, err = io.ReadAll(r)
+		done <- err
+	}()
+	exitCode, werr := process.Wait()
+	if ctx.Err() != nil {
+		// Another process may still hold the write end; closing
+		// the read end cancels the pending read.
+		r.Close()
+	}
+	if rerr := <-done; werr == nil && ctx.Err() == nil {
+		werr = rerr
+	}
+	if err == nil {
+		err = werr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil && exitCode != 0 {
+		err = &ExitError{ExitCode: exitCode}
+	}
+	return
+}
+
+// pipe creates an anonymous pipe for communicating with a WSL
+// process. Only the end that is handed to the process, the read end
+// if childReads is set and the write end otherwise, is inheritable,
+// so that it works whether wslapi.dll duplicates the standard handles
+// or starts its relay with handle inheritance. Our end stays
+// non-inheritable so that it does not leak into unrelated child
+// processes, which would keep the pipe open.
+func pipe(childReads bool) (r, w *os.File, err error) {
+	var rh, wh windows.Handle
+	sa := windows.SecurityAttributes{InheritHandle: 1}
+	sa.Length = uint32(unsafe.Sizeof(sa))
+	if err = windows.CreatePipe(&rh, &wh, &sa, 0); err != nil {
+		return
+	}
+	parent := rh
+	if childReads {
+		parent = wh
+	}
+	if err = windows.SetHandleInformation(parent, windows.HANDLE_FLAG_INHERIT, 0); err != nil {
+		windows.CloseHandle(rh)
+		windows.CloseHandle(wh)
+		return
+	}
+	return os.NewFile(uintptr(rh), "|0"), os.NewFile(uintptr(wh), "|1"), nil
+}
+
+// LaunchPipes launches a WSL process like Launch with its standard
+// streams connected to pipes and returns the other ends of the pipes.
+// Closing stdin signals end-of-file to the process; reading from
+// stdout and stderr returns io.EOF once the process has closed them.
+//
+// The caller is responsible for closing the pipes and the process
+// handle. Both stdout and stderr have to be drained, otherwise the
+// process may block on a full pipe buffer.
+func LaunchPipes(name, command string, useCwd bool) (stdin io.WriteCloser, stdout, stderr io.ReadCloser, process windows.Handle, err error) {
+	process = windows.InvalidHandle
+	inR, inW, err := pipe(true)
+	if err != nil {
+		return
+	}
+	defer inR.Close()
+	outR, outW, err := pipe(false)
+	if err != nil {
+		inW.Close()
+		return
+	}
+	defer outW.Close()
+	errR, errW, err := pipe(false)
+	if err != nil {
+		inW.Close()
+		outR.Close()
+		return
+	}
+	defer errW.Close()
+	if process, err = Launch(name, command, useCwd,
+		windows.Handle(inR.Fd()), windows.Handle(outW.Fd()), windows.Handle(errW.Fd())); err != nil {
+		inW.Close()
+		outR.Close()
+		errR.Close()
+		return
+	}
+	return inW, outR, errR, process, nil
+}
@@ -0,0 +1,65 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cancelScript prints a line and then keeps running, with a background
+// process holding on to the output.
+const cancelScript = "echo started; sleep 60 & sleep 60"
+
+func TestLaunchCombinedOutputContextCancel(t *testing.T) {
+	name := testDistribution(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	start := time.Now()
+	out, err := LaunchCombinedOutputContext(ctx, name, shellCommand(cancelScript), false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if !strings.Contains(string(out), "started") {
+		t.Errorf("output %q lacks the line printed before the deadline", out)
+	}
+	if d := time.Since(start); d > 30*time.Second {
+		t.Errorf("returned after %v", d)
+	}
+}
+
+func TestRunResultContextCancel(t *testing.T) {
+	name := testDistribution(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	start := time.Now()
+	res, err := RunResultContext(ctx, name, shellCommand(cancelScript), nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if !strings.Contains(string(res.Stdout), "started") {
+		t.Errorf("stdout %q lacks the line printed before the deadline", res.Stdout)
+	}
+	if d := time.Since(start); d > 30*time.Second {
+		t.Errorf("returned after %v", d)
+	}
+}
@@ -0,0 +1,128 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// ProcessHandle wraps the handle of a Windows Subsystem for Linux
+// (WSL) process started by LaunchProcess. It takes care of waiting
+// for the process and releasing its handle exactly once.
+type ProcessHandle struct {
+	mu       sync.Mutex
+	handle   windows.Handle
+	done     bool
+	exitCode uint32
+	err      error
+}
+
+// newProcessHandle wraps h. If the ProcessHandle is garbage collected
+// without Wait or Close having been called, a warning is logged and
+// the handle is closed.
+func newProcessHandle(h windows.Handle) *ProcessHandle {
+	p := &ProcessHandle{handle: h}
+	runtime.SetFinalizer(p, func(p *ProcessHandle) {
+		if !p.done {
+			log.Printf("wsl: process handle %#x leaked, closing", p.handle)
+			windows.CloseHandle(p.handle)
+		}
+	})
+	return p
+}
+
+// LaunchProcess launches a WSL process like Launch, but returns a
+// ProcessHandle which takes care of waiting for the process and
+// releasing its handle.
+func LaunchProcess(name string, command string, useCwd bool, stdin, stdout, stderr windows.Handle) (*ProcessHandle, error) {
+	h, err := Launch(name, command, useCwd, stdin, stdout, stderr)
+	if err != nil {
+		return nil, err
+	}
+	return newProcessHandle(h), nil
+}
+
+// Handle returns the process handle. It remains valid until Wait or
+// Close returns.
+func (p *ProcessHandle) Handle() windows.Handle {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.handle
+}
+
+// Wait waits for the process to exit and returns its exit code. The
+// process handle is closed in any case. Subsequent calls return the
+// result of the first call.
+func (p *ProcessHandle) Wait() (uint32, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return p.exitCode, p.err
+	}
+	p.exitCode, p.err = WaitForExit(p.handle)
+	p.release()
+	return p.exitCode, p.err
+}
+
+// WaitForExit waits for the process h to exit and returns its exit
+// code. Since the exit code is only read once the process has exited,
+// STILL_ACTIVE (259) is never mistaken for an exit code, unless the
+// process actually exited with it. The caller remains responsible for
+// closing h; ProcessHandle takes care of both.
+func WaitForExit(h windows.Handle) (uint32, error) {
+	ev, err := windows.WaitForSingleObject(h, windows.INFINITE)
+	if err != nil {
+		return 0, err
+	}
+	if ev != windows.WAIT_OBJECT_0 {
+		return 0, fmt.Errorf("WaitForSingleObject: unexpected result %#x", ev)
+	}
+	var exitCode uint32
+	if err := windows.GetExitCodeProcess(h, &exitCode); err != nil {
+		return 0, err
+	}
+	return exitCode, nil
+}
+
+// Close closes the process handle without waiting for the process,
+// which keeps running. Closing an already closed or waited-for
+// ProcessHandle is a no-op.
+func (p *ProcessHandle) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return nil
+	}
+	p.err = ErrProcessClosed
+	return p.release()
+}
+
+// release closes the handle and marks p as done. p.mu must be held.
+func (p *ProcessHandle) release() error {
+	p.done = true
+	err := windows.CloseHandle(p.handle)
+	p.handle = windows.InvalidHandle
+	runtime.SetFinalizer(p, nil)
+	return err
+}
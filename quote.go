@@ -0,0 +1,59 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"strings"
+)
+
+// ShellQuote quotes arg for use as a single word in a POSIX shell
+// command line, such as the command passed to Launch or
+// LaunchInteractive.
+//
+// The argument is enclosed in single quotes, and each embedded single
+// quote is closed, escaped, and reopened:
+//
+//	it's  ->  'it'\''s'
+//
+// No characters are special inside single quotes, so spaces,
+// newlines, and $ are passed through literally.
+func ShellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// ShellQuoteAll quotes each element of args using ShellQuote and
+// joins them with spaces.
+func ShellQuoteAll(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// LaunchArgs launches a WSL process like Launch, running the program
+// argv[0] with the arguments argv[1:]. Although WslLaunch takes a
+// Windows string, the command is interpreted by a shell inside the
+// distribution, so the arguments are quoted using ShellQuoteAll
+// rather than Windows command line rules.
+func LaunchArgs(name string, argv []string, useCwd bool, stdin, stdout, stderr Handle) (Handle, error) {
+	if len(argv) == 0 {
+		return InvalidHandle, errors.New("LaunchArgs: empty argv")
+	}
+	return Launch(name, ShellQuoteAll(argv), useCwd, stdin, stdout, stderr)
+}
@@ -0,0 +1,75 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	for _, c := range []struct{ arg, want string }{
+		{"", `''`},
+		{"plain", `'plain'`},
+		{"two words", `'two words'`},
+		{"it's", `'it'\''s'`},
+		{"''", `''\'''\'''`},
+		{"a\nb", "'a\nb'"},
+		{"$HOME `id` \\", "'$HOME `id` \\'"},
+		{`"double"`, `'"double"'`},
+	} {
+		if got := ShellQuote(c.arg); got != c.want {
+			t.Errorf("ShellQuote(%q) = %s, want %s", c.arg, got, c.want)
+		}
+	}
+}
+
+func TestShellQuoteAll(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{""}, `''`},
+		{[]string{"ls", "-l", "my dir"}, `'ls' '-l' 'my dir'`},
+		{[]string{"echo", "it's", "$x"}, `'echo' 'it'\''s' '$x'`},
+	} {
+		if got := ShellQuoteAll(c.args); got != c.want {
+			t.Errorf("ShellQuoteAll(%q) = %s, want %s", c.args, got, c.want)
+		}
+	}
+}
+
+// TestShellQuoteRoundTrip checks that a POSIX shell turns each quoted
+// argument back into the original string.
+func TestShellQuoteRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh:", err)
+	}
+	args := []string{"", "two words", "it's", "a\nb", "$HOME", "`id`", `back\slash\`, "*", `"`}
+	out, err := exec.Command(sh, "-c", `printf '%s\0' `+ShellQuoteAll(args)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("got %q, want %q", got, args)
+	}
+}
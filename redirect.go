@@ -0,0 +1,72 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// RunRedirect runs command in the context of a particular
+// distribution with its standard streams connected to files, similar
+// to shell redirection. An empty path leaves the corresponding stream
+// connected to the current process. Output files are created or
+// truncated. RunRedirect waits for the command to finish and returns
+// its exit code.
+func RunRedirect(name, command string, stdinPath, stdoutPath, stderrPath string) (exitCode uint32, err error) {
+	var stdin, stdout, stderr windows.Handle
+	var f *os.File
+	if stdin, f, err = redirect(stdinPath, windows.STD_INPUT_HANDLE, os.Open); err != nil {
+		return
+	} else if f != nil {
+		defer f.Close()
+	}
+	if stdout, f, err = redirect(stdoutPath, windows.STD_OUTPUT_HANDLE, os.Create); err != nil {
+		return
+	} else if f != nil {
+		defer f.Close()
+	}
+	if stderr, f, err = redirect(stderrPath, windows.STD_ERROR_HANDLE, os.Create); err != nil {
+		return
+	} else if f != nil {
+		defer f.Close()
+	}
+	var process *ProcessHandle
+	if process, err = LaunchProcess(name, command, false, stdin, stdout, stderr); err != nil {
+		return
+	}
+	return process.Wait()
+}
+
+// redirect returns a handle for path opened with open, or the current
+// process' standard handle std if path is empty. If a file has been
+// opened, it is returned as well and must be closed by the caller.
+func redirect(path string, std uint32, open func(string) (*os.File, error)) (windows.Handle, *os.File, error) {
+	if path == "" {
+		h, err := windows.GetStdHandle(std)
+		return h, nil, err
+	}
+	f, err := open(path)
+	if err != nil {
+		return windows.InvalidHandle, nil, fmt.Errorf("redirect: %w", err)
+	}
+	return windows.Handle(f.Fd()), f, nil
+}
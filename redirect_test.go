@@ -0,0 +1,53 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunRedirect(t *testing.T) {
+	name := testDistribution(t)
+	dir := t.TempDir()
+	stdinPath := filepath.Join(dir, "stdin")
+	stdoutPath := filepath.Join(dir, "stdout")
+	stderrPath := filepath.Join(dir, "stderr")
+	if err := os.WriteFile(stdinPath, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exitCode, err := RunRedirect(name, shellCommand("cat; echo oops >&2; exit 3"),
+		stdinPath, stdoutPath, stderrPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != 3 {
+		t.Errorf("exit code = %d, want 3", exitCode)
+	}
+	for path, want := range map[string]string{stdoutPath: "hello\n", stderrPath: "oops\n"} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
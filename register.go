@@ -0,0 +1,139 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// RegisterDistributionReader registers a new distribution like
+// RegisterDistribution, reading the .tar.gz root filesystem from r.
+// Since WslRegisterDistribution only accepts a file name, the tarball
+// is written to a temporary file that is removed afterwards.
+//
+// The temporary file is created in the directory of the executable,
+// which is where WslRegisterDistribution installs the distribution
+// for unpackaged callers, so that the copy stays on the same volume.
+// If that directory cannot be determined, the user's temporary
+// directory is used.
+func RegisterDistributionReader(name string, r io.Reader) error {
+	return RegisterDistributionReaderContext(context.Background(), name, r, nil)
+}
+
+// RegisterDistributionReaderContext registers a new distribution like
+// RegisterDistributionReader. progress, if not nil, is called with the
+// number of bytes read from r so far while the tarball is staged in
+// the temporary file. If ctx is done during staging, it is aborted.
+// Registration itself is handled as with RegisterDistributionContext.
+func RegisterDistributionReaderContext(ctx context.Context, name string, r io.Reader, progress func(bytesRead int64)) error {
+	f, err := os.CreateTemp(stagingDir(), "go-wsl-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, &progressReader{ctx: ctx, r: r, progress: progress})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return registerContext(ctx, name, f.Name())
+}
+
+// stagingDir returns the directory in which tarballs read from an
+// io.Reader are staged, see RegisterDistributionReader. "" stands for
+// the temporary directory.
+func stagingDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	return filepath.Dir(exe)
+}
+
+// RegisterDistributionContext registers a new distribution like
+// RegisterDistribution. WslRegisterDistribution reads the tarball
+// itself and does not report its progress, so progress, if not nil,
+// is only called with 0 before registration starts and with the size
+// of the tarball once it has succeeded.
+//
+// WslRegisterDistribution cannot be interrupted: if ctx is done while
+// it runs, RegisterDistributionContext waits for it to finish and
+// unregisters the new distribution again, returning ctx.Err().
+func RegisterDistributionContext(ctx context.Context, name, tarball string, progress func(bytesRead int64)) error {
+	fi, err := os.Stat(tarball)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if progress != nil {
+		progress(0)
+	}
+	if err := registerContext(ctx, name, tarball); err != nil {
+		return err
+	}
+	if progress != nil {
+		progress(fi.Size())
+	}
+	return nil
+}
+
+// registerContext registers tarball, undoing the registration if ctx
+// is done before it has finished.
+func registerContext(ctx context.Context, name, tarball string) error {
+	if ctx.Done() == nil {
+		return RegisterDistribution(name, tarball)
+	}
+	done := make(chan error, 1)
+	go func() { done <- RegisterDistribution(name, tarball) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if err := <-done; err == nil {
+			UnregisterDistribution(name)
+		}
+		return ctx.Err()
+	}
+}
+
+// progressReader reads from r, reporting the number of bytes read so
+// far to progress and failing once ctx is done.
+type progressReader struct {
+	ctx      context.Context
+	r        io.Reader
+	progress func(int64)
+	n        int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if n > 0 && p.progress != nil {
+		p.progress(p.n)
+	}
+	return n, err
+}
@@ -0,0 +1,85 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegisterDistributionContext(t *testing.T) {
+	f := useFakeAPI(t, map[string]*DistributionConfig{})
+	tarball := filepath.Join(t.TempDir(), "rootfs.tar.gz")
+	if err := os.WriteFile(tarball, make([]byte, 1234), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []int64
+	progress := func(n int64) { calls = append(calls, n) }
+	if err := RegisterDistributionContext(context.Background(), "test", tarball, progress); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{0, 1234}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("progress called with %v, want %v", calls, want)
+	}
+	if _, ok := f.distributions["test"]; !ok {
+		t.Error("distribution not registered")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := RegisterDistributionContext(ctx, "canceled", tarball, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if _, ok := f.distributions["canceled"]; ok {
+		t.Error("distribution registered despite canceled context")
+	}
+
+	if err := RegisterDistributionContext(context.Background(), "missing", tarball+".missing", nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestRegisterDistributionReaderContext(t *testing.T) {
+	f := useFakeAPI(t, map[string]*DistributionConfig{})
+
+	var last int64
+	r := bytes.NewReader(make([]byte, 100000))
+	if err := RegisterDistributionReaderContext(context.Background(), "test", r, func(n int64) { last = n }); err != nil {
+		t.Fatal(err)
+	}
+	if last != 100000 {
+		t.Errorf("last progress %d, want 100000", last)
+	}
+	if _, ok := f.distributions["test"]; !ok {
+		t.Error("distribution not registered")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := RegisterDistributionReaderContext(ctx, "canceled", r, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if _, ok := f.distributions["canceled"]; ok {
+		t.Error("distribution registered despite canceled context")
+	}
+}
@@ -0,0 +1,366 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// lxssKey is the registry key below HKEY_CURRENT_USER that holds one
+// sub-key per registered distribution, named by its GUID.
+const lxssKey = `Software\Microsoft\Windows\CurrentVersion\Lxss`
+
+// ListDistributions returns the sorted names of the distributions
+// registered for the current user. Sub-keys of the Lxss registry key
+// without a DistributionName value are skipped.
+func ListDistributions() ([]string, error) {
+	distributions, err := readDistributions()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, d := range distributions {
+		names = append(names, d.name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetDefaultDistribution returns the name of the distribution that
+// wsl.exe launches if no distribution is specified. If no default
+// has been set, ErrNoDefaultDistribution is returned.
+func GetDefaultDistribution() (string, error) {
+	k, err := userHive.OpenKey(lxssKey, registry.QUERY_VALUE)
+	if err == registry.ErrNotExist {
+		return "", ErrNoDefaultDistribution
+	} else if err != nil {
+		return "", err
+	}
+	defer k.Close()
+	id, err := k.GetStringValue("DefaultDistribution")
+	if err == registry.ErrNotExist || (err == nil && id == "") {
+		return "", ErrNoDefaultDistribution
+	} else if err != nil {
+		return "", err
+	}
+	dk, err := k.OpenKey(id, registry.QUERY_VALUE)
+	if err == registry.ErrNotExist {
+		return "", fmt.Errorf("default distribution %s: %w", id, ErrDistributionNotFound)
+	} else if err != nil {
+		return "", err
+	}
+	defer dk.Close()
+	name, err := dk.GetStringValue("DistributionName")
+	if err != nil {
+		return "", fmt.Errorf("default distribution %s: %w", id, err)
+	}
+	return name, nil
+}
+
+// SetDefaultDistribution makes the named distribution the one that
+// wsl.exe launches if no distribution is specified.
+func SetDefaultDistribution(name string) error {
+	id, err := GetDistributionID(name)
+	if err != nil {
+		return err
+	}
+	k, err := userHive.OpenKey(lxssKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringValue("DefaultDistribution", id)
+}
+
+// SafeUnregisterDistribution unregisters a distribution like
+// UnregisterDistribution. If that leaves the default distribution
+// pointing to a distribution that no longer exists, the first of the
+// remaining distributions becomes the default, or the default is
+// cleared if none are left.
+func SafeUnregisterDistribution(name string) error {
+	if err := UnregisterDistribution(name); err != nil {
+		return err
+	}
+	if _, err := GetDefaultDistribution(); !errors.Is(err, ErrDistributionNotFound) {
+		return nil
+	}
+	names, err := ListDistributions()
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return SetDefaultDistribution(names[0])
+	}
+	k, err := userHive.OpenKey(lxssKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.DeleteValue("DefaultDistribution")
+}
+
+// AllUsersDistributions returns the distributions registered for
+// each user whose registry hive is loaded, typically the logged-in
+// users, mapped by the user's SID. Users without distributions are
+// omitted. Reading other users' hives usually requires running as
+// a service or with administrative privileges.
+func AllUsersDistributions() (map[string][]UserDistribution, error) {
+	sids, err := usersHive.ReadSubKeyNames()
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string][]UserDistribution)
+	for _, sid := range sids {
+		// Skip .DEFAULT and the per-user classes hives.
+		if !strings.HasPrefix(sid, "S-") || strings.HasSuffix(sid, "_Classes") {
+			continue
+		}
+		uk, err := usersHive.OpenKey(sid, registry.ENUMERATE_SUB_KEYS)
+		if err != nil {
+			continue
+		}
+		distributions, err := readUserDistributions(uk)
+		uk.Close()
+		if err != nil || len(distributions) == 0 {
+			continue
+		}
+		var ds []UserDistribution
+		for _, d := range distributions {
+			ds = append(ds, UserDistribution{SID: sid, Name: d.name, BasePath: d.basePath})
+		}
+		sort.Slice(ds, func(i, j int) bool { return ds[i].Name < ds[j].Name })
+		all[sid] = ds
+	}
+	return all, nil
+}
+
+// lxssDistribution holds the registry values of a distribution's
+// Lxss sub-key that are used by this package.
+type lxssDistribution struct {
+	id       string
+	name     string
+	basePath string
+	flags    uint32
+}
+
+// readDistributions reads the Lxss sub-keys of the distributions
+// registered for the current user.
+func readDistributions() ([]lxssDistribution, error) {
+	return readUserDistributions(userHive)
+}
+
+// readUserDistributions reads the Lxss sub-keys of the distributions
+// registered in the user hive root.
+func readUserDistributions(root regKey) ([]lxssDistribution, error) {
+	k, err := root.OpenKey(lxssKey, registry.ENUMERATE_SUB_KEYS)
+	if err == registry.ErrNotExist {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+	ids, err := k.ReadSubKeyNames()
+	if err != nil {
+		return nil, err
+	}
+	var distributions []lxssDistribution
+	for _, id := range ids {
+		dk, err := k.OpenKey(id, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		d := lxssDistribution{id: id}
+		d.name, err = dk.GetStringValue("DistributionName")
+		if err == nil && d.name != "" {
+			d.basePath, _ = dk.GetStringValue("BasePath")
+			if flags, err := dk.GetIntegerValue("Flags"); err == nil {
+				d.flags = uint32(flags)
+			}
+			distributions = append(distributions, d)
+		}
+		dk.Close()
+	}
+	return distributions, nil
+}
+
+// GetDistributionID returns the GUID that identifies the named
+// distribution, which is the name of its sub-key of
+// HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Lxss.
+// Names are compared case-insensitively. If no such distribution is
+// registered, ErrDistributionNotFound is returned.
+func GetDistributionID(name string) (string, error) {
+	d, err := findDistribution(name)
+	if err != nil {
+		return "", err
+	}
+	return d.id, nil
+}
+
+// findDistribution returns the Lxss sub-key values of the named
+// distribution. Like WSL itself, it compares names case-insensitively.
+func findDistribution(name string) (lxssDistribution, error) {
+	distributions, err := readDistributions()
+	if err != nil {
+		return lxssDistribution{}, err
+	}
+	for _, d := range distributions {
+		if strings.EqualFold(d.name, name) {
+			return d, nil
+		}
+	}
+	return lxssDistribution{}, fmt.Errorf("%s: %w", name, ErrDistributionNotFound)
+}
+
+// lxssFlagVersion2 is set in the Flags value of a distribution's Lxss
+// sub-key if the distribution runs under WSL2.
+const lxssFlagVersion2 = 0x8
+
+// GetWSLVersion returns 2 if the named distribution runs under WSL2
+// and 1 if it runs under WSL1. This is unrelated to the version
+// returned by GetDistributionConfiguration. If no such distribution
+// is registered, ErrDistributionNotFound is returned.
+func GetWSLVersion(name string) (int, error) {
+	d, err := findDistribution(name)
+	if err != nil {
+		return 0, err
+	}
+	if d.flags&lxssFlagVersion2 != 0 {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+// SetDistributionEnvironment replaces the default environment of a
+// distribution, as returned by GetDistributionEnvironment, with env.
+// The entries are written in sorted order.
+//
+// WslConfigureDistribution cannot change the environment, so the
+// DefaultEnvironment value of the distribution's Lxss registry key is
+// written directly. The change takes effect for processes started
+// after the distribution has been restarted.
+func SetDistributionEnvironment(name string, env map[string]string) error {
+	id, err := GetDistributionID(name)
+	if err != nil {
+		return err
+	}
+	entries := make([]string, 0, len(env))
+	for key, value := range env {
+		if key == "" || strings.ContainsAny(key, "=\x00") || strings.ContainsRune(value, 0) {
+			return fmt.Errorf("invalid environment variable %q", key)
+		}
+		entries = append(entries, key+"="+value)
+	}
+	sort.Strings(entries)
+	k, err := userHive.OpenKey(lxssKey+`\`+id, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringsValue("DefaultEnvironment", entries)
+}
+
+// RenameDistribution changes the name of a registered distribution by
+// updating the DistributionName value of its Lxss registry key. The
+// distribution should not be running while it is renamed. If newName
+// differs from oldName only in case, the distribution is renamed in
+// place; otherwise, ErrAlreadyExists is returned if newName is taken.
+func RenameDistribution(oldName, newName string) error {
+	if err := validateName(newName); err != nil {
+		return err
+	}
+	id, err := GetDistributionID(oldName)
+	if err != nil {
+		return err
+	}
+	if other, err := GetDistributionID(newName); err == nil && other != id {
+		return fmt.Errorf("%s: %w", newName, ErrAlreadyExists)
+	} else if err != nil && !errors.Is(err, ErrDistributionNotFound) {
+		return err
+	}
+	k, err := userHive.OpenKey(lxssKey+`\`+id, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringValue("DistributionName", newName)
+}
+
+// InspectDistribution returns information about the named
+// distribution, collected from one pass over the Lxss registry key and
+// one call to GetDistributionConfiguration. If no such distribution
+// is registered, the returned DistributionInfo has only Name set.
+func InspectDistribution(name string) (*DistributionInfo, error) {
+	d, err := findDistribution(name)
+	if errors.Is(err, ErrDistributionNotFound) {
+		return &DistributionInfo{Name: name}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	info := &DistributionInfo{Name: d.name, Registered: true, Version: 1}
+	if d.flags&lxssFlagVersion2 != 0 {
+		info.Version = 2
+	}
+	if p, err := d.basePathAbs(); err == nil {
+		info.BasePath = p
+	}
+	if _, info.DefaultUID, info.Flags, info.Environment, err = GetDistributionConfiguration(d.name); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// lxssValues formats all values of the named distribution's Lxss
+// sub-key as "name = value" lines.
+func lxssValues(name string) ([]byte, error) {
+	d, err := findDistribution(name)
+	if err != nil {
+		return nil, err
+	}
+	k, err := userHive.OpenKey(lxssKey+`\`+d.id, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+	names, err := k.ReadValueNames()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Key = %s\\%s\n", lxssKey, d.id)
+	for _, n := range names {
+		var value interface{}
+		if s, err := k.GetStringValue(n); err == nil {
+			value = s
+		} else if i, err := k.GetIntegerValue(n); err == nil {
+			value = fmt.Sprintf("%#x", i)
+		} else if ss, err := k.GetStringsValue(n); err == nil {
+			value = ss
+		} else {
+			value = err
+		}
+		fmt.Fprintf(&b, "%s = %v\n", n, value)
+	}
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,431 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// fakeKey is an in-memory registry key. Values are string, []string
+// or uint64. Like the real registry, names are case-insensitive.
+type fakeKey struct {
+	mu      *sync.Mutex
+	name    string
+	values  map[string]interface{}
+	subkeys []*fakeKey
+}
+
+// newFakeHive returns the root of an empty in-memory registry.
+func newFakeHive() *fakeKey {
+	return &fakeKey{mu: new(sync.Mutex), values: map[string]interface{}{}}
+}
+
+// create returns the sub-key at path, creating missing keys.
+func (k *fakeKey) create(path string) *fakeKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, name := range strings.Split(path, `\`) {
+		sk := k.sub(name)
+		if sk == nil {
+			sk = &fakeKey{mu: k.mu, name: name, values: map[string]interface{}{}}
+			k.subkeys = append(k.subkeys, sk)
+		}
+		k = sk
+	}
+	return k
+}
+
+// set sets the registry values in values, returning k.
+func (k *fakeKey) set(values map[string]interface{}) *fakeKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for name, v := range values {
+		k.values[name] = v
+	}
+	return k
+}
+
+// remove deletes the sub-key name with all its contents.
+func (k *fakeKey) remove(name string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i, sk := range k.subkeys {
+		if strings.EqualFold(sk.name, name) {
+			k.subkeys = append(k.subkeys[:i], k.subkeys[i+1:]...)
+			return
+		}
+	}
+}
+
+func (k *fakeKey) sub(name string) *fakeKey {
+	for _, sk := range k.subkeys {
+		if strings.EqualFold(sk.name, name) {
+			return sk
+		}
+	}
+	return nil
+}
+
+func (k *fakeKey) value(name string) (interface{}, error) {
+	for n, v := range k.values {
+		if strings.EqualFold(n, name) {
+			return v, nil
+		}
+	}
+	return nil, registry.ErrNotExist
+}
+
+func (k *fakeKey) OpenKey(path string, access uint32) (regKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if path == "" {
+		return k, nil
+	}
+	for _, name := range strings.Split(path, `\`) {
+		if k = k.sub(name); k == nil {
+			return nil, registry.ErrNotExist
+		}
+	}
+	return k, nil
+}
+
+func (k *fakeKey) ReadSubKeyNames() ([]string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var names []string
+	for _, sk := range k.subkeys {
+		names = append(names, sk.name)
+	}
+	return names, nil
+}
+
+func (k *fakeKey) ReadValueNames() ([]string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var names []string
+	for n := range k.values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (k *fakeKey) GetStringValue(name string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	v, err := k.value(name)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", registry.ErrUnexpectedType
+	}
+	return s, nil
+}
+
+func (k *fakeKey) GetStringsValue(name string) ([]string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	v, err := k.value(name)
+	if err != nil {
+		return nil, err
+	}
+	ss, ok := v.([]string)
+	if !ok {
+		return nil, registry.ErrUnexpectedType
+	}
+	return append([]string(nil), ss...), nil
+}
+
+func (k *fakeKey) GetIntegerValue(name string) (uint64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	v, err := k.value(name)
+	if err != nil {
+		return 0, err
+	}
+	i, ok := v.(uint64)
+	if !ok {
+		return 0, registry.ErrUnexpectedType
+	}
+	return i, nil
+}
+
+func (k *fakeKey) SetStringValue(name, value string) error {
+	k.set(map[string]interface{}{name: value})
+	return nil
+}
+
+func (k *fakeKey) SetStringsValue(name string, value []string) error {
+	k.set(map[string]interface{}{name: append([]string(nil), value...)})
+	return nil
+}
+
+func (k *fakeKey) DeleteValue(name string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for n := range k.values {
+		if strings.EqualFold(n, name) {
+			delete(k.values, n)
+			return nil
+		}
+	}
+	return registry.ErrNotExist
+}
+
+func (k *fakeKey) Close() error { return nil }
+
+// addLxss registers a distribution with the given ID, name and
+// BasePath in the user hive, returning its Lxss sub-key.
+func (k *fakeKey) addLxss(id, name, basePath string) *fakeKey {
+	return k.create(lxssKey + `\` + id).set(map[string]interface{}{
+		"DistributionName": name,
+		"BasePath":         basePath,
+		"Flags":            uint64(0xf),
+	})
+}
+
+// useFakeRegistry makes the registry functions use user as the
+// current user's hive and users as HKEY_USERS for the duration of the
+// test. Either may be nil to keep the real one.
+func useFakeRegistry(t *testing.T, user, users *fakeKey) {
+	t.Helper()
+	oldUser, oldUsers := userHive, usersHive
+	if user != nil {
+		userHive = user
+	}
+	if users != nil {
+		usersHive = users
+	}
+	t.Cleanup(func() { userHive, usersHive = oldUser, oldUsers })
+}
+
+func TestAllUsersDistributions(t *testing.T) {
+	const (
+		alice = "S-1-5-21-1000"
+		bob   = "S-1-5-21-1001"
+		carol = "S-1-5-21-1002"
+	)
+	users := newFakeHive()
+	users.create(".DEFAULT").addLxss("{00000000-0000-0000-0000-000000000000}", "Ignored", `C:\ignored`)
+	a := users.create(alice)
+	a.addLxss("{11111111-1111-1111-1111-111111111111}", "Ubuntu", `C:\Users\alice\Ubuntu`)
+	a.addLxss("{22222222-2222-2222-2222-222222222222}", "Debian", `\\?\C:\Users\alice\Debian`)
+	// The Lxss key without a DistributionName does not count.
+	a.create(lxssKey + `\{33333333-3333-3333-3333-333333333333}`)
+	users.create(bob).addLxss("{44444444-4444-4444-4444-444444444444}", "Ubuntu", `%LOCALAPPDATA%\Ubuntu`)
+	users.create(bob+"_Classes").addLxss("{55555555-5555-5555-5555-555555555555}", "Ignored", `C:\ignored`)
+	// A user without distributions is omitted.
+	users.create(carol + `\Software`)
+	useFakeRegistry(t, nil, users)
+
+	got, err := AllUsersDistributions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]UserDistribution{
+		alice: {
+			{SID: alice, Name: "Debian", BasePath: `\\?\C:\Users\alice\Debian`},
+			{SID: alice, Name: "Ubuntu", BasePath: `C:\Users\alice\Ubuntu`},
+		},
+		bob: {
+			{SID: bob, Name: "Ubuntu", BasePath: `%LOCALAPPDATA%\Ubuntu`},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// lxssAPI is an API backed by a fake user hive in the way WSL stores
+// distributions: each is a sub-key of the Lxss key.
+type lxssAPI struct {
+	hive *fakeKey
+}
+
+// useLxssAPI makes both the registry functions and the package
+// functions use hive for the duration of the test.
+func useLxssAPI(t *testing.T, hive *fakeKey) {
+	t.Helper()
+	useFakeRegistry(t, hive, nil)
+	old := Default
+	Default = lxssAPI{hive}
+	t.Cleanup(func() { Default = old })
+}
+
+// find returns the Lxss sub-key of the named distribution.
+func (a lxssAPI) find(name string) (*fakeKey, error) {
+	lxss := a.hive.create(lxssKey)
+	ids, _ := lxss.ReadSubKeyNames()
+	for _, id := range ids {
+		k := lxss.create(id)
+		if n, err := k.GetStringValue("DistributionName"); err == nil && strings.EqualFold(n, name) {
+			return k, nil
+		}
+	}
+	return nil, ErrDistributionNotFound
+}
+
+func (a lxssAPI) Configure(name string, defaultUID uint32, flags DistributionFlags) error {
+	k, err := a.find(name)
+	if err != nil {
+		return err
+	}
+	old, _ := k.GetIntegerValue("Flags")
+	k.set(map[string]interface{}{
+		"DefaultUid": uint64(defaultUID),
+		"Flags":      old&lxssFlagVersion2 | uint64(flags),
+	})
+	return nil
+}
+
+func (a lxssAPI) GetConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
+	k, err := a.find(name)
+	if err != nil {
+		return
+	}
+	uid, _ := k.GetIntegerValue("DefaultUid")
+	f, _ := k.GetIntegerValue("Flags")
+	environment, _ = k.GetStringsValue("DefaultEnvironment")
+	return 2, uint32(uid), DistributionFlags(f &^ lxssFlagVersion2), environment, nil
+}
+
+func (a lxssAPI) IsRegistered(name string) bool {
+	_, err := a.find(name)
+	return err == nil
+}
+
+func (a lxssAPI) Launch(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (Handle, error) {
+	return InvalidHandle, ErrUnsupportedPlatform
+}
+
+func (a lxssAPI) LaunchInteractive(name string, command string, useCwd bool) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func (a lxssAPI) Register(name string, tarball string) error {
+	return ErrUnsupportedPlatform
+}
+
+func (a lxssAPI) Unregister(name string) error {
+	k, err := a.find(name)
+	if err != nil {
+		return err
+	}
+	a.hive.create(lxssKey).remove(k.name)
+	return nil
+}
+
+func TestSetDistributionEnvironment(t *testing.T) {
+	hive := newFakeHive()
+	k := hive.addLxss("{11111111-1111-1111-1111-111111111111}", "Ubuntu", `C:\Ubuntu`)
+	k.set(map[string]interface{}{"DefaultEnvironment": []string{"OLD=1"}})
+	useLxssAPI(t, hive)
+
+	env := map[string]string{
+		"PATH":  "/usr/local/bin:/usr/bin:/bin",
+		"EMPTY": "",
+		"EQ":    "a=b",
+	}
+	if err := SetDistributionEnvironment("ubuntu", env); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetDistributionEnvironment("Ubuntu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, env) {
+		t.Errorf("got %v, want %v", got, env)
+	}
+	stored, _ := k.GetStringsValue("DefaultEnvironment")
+	if want := []string{"EMPTY=", "EQ=a=b", "PATH=/usr/local/bin:/usr/bin:/bin"}; !reflect.DeepEqual(stored, want) {
+		t.Errorf("stored %q, want %q", stored, want)
+	}
+
+	for _, bad := range []map[string]string{{"": "x"}, {"A=B": "x"}, {"A": "x\x00y"}} {
+		if err := SetDistributionEnvironment("Ubuntu", bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+	if after, _ := GetDistributionEnvironment("Ubuntu"); !reflect.DeepEqual(after, env) {
+		t.Errorf("environment changed to %v by invalid input", after)
+	}
+	if err := SetDistributionEnvironment("Missing", env); !errors.Is(err, ErrDistributionNotFound) {
+		t.Errorf("got %v, want ErrDistributionNotFound", err)
+	}
+}
+
+func TestSafeUnregisterDistribution(t *testing.T) {
+	const (
+		ubuntu = "{11111111-1111-1111-1111-111111111111}"
+		debian = "{22222222-2222-2222-2222-222222222222}"
+		alpine = "{33333333-3333-3333-3333-333333333333}"
+	)
+	hive := newFakeHive()
+	hive.addLxss(ubuntu, "Ubuntu", `C:\wsl\Ubuntu`)
+	hive.addLxss(debian, "Debian", `C:\wsl\Debian`)
+	hive.addLxss(alpine, "Alpine", `C:\wsl\Alpine`)
+	hive.create(lxssKey).set(map[string]interface{}{"DefaultDistribution": ubuntu})
+	useLxssAPI(t, hive)
+
+	wantDefault := func(want string) {
+		t.Helper()
+		got, err := GetDefaultDistribution()
+		if err != nil || got != want {
+			t.Errorf("default is %q, %v; want %q", got, err, want)
+		}
+	}
+
+	// Unregistering fails: the default stays.
+	if err := SafeUnregisterDistribution("Missing"); !errors.Is(err, ErrDistributionNotFound) {
+		t.Errorf("got %v, want ErrDistributionNotFound", err)
+	}
+	wantDefault("Ubuntu")
+
+	// Not the default: the default stays.
+	if err := SafeUnregisterDistribution("Debian"); err != nil {
+		t.Fatal(err)
+	}
+	wantDefault("Ubuntu")
+
+	// The default: the first remaining distribution takes over.
+	if err := SafeUnregisterDistribution("Ubuntu"); err != nil {
+		t.Fatal(err)
+	}
+	wantDefault("Alpine")
+	if id, _ := hive.create(lxssKey).GetStringValue("DefaultDistribution"); id != alpine {
+		t.Errorf("DefaultDistribution = %s, want %s", id, alpine)
+	}
+
+	// The last one: the default is cleared.
+	if err := SafeUnregisterDistribution("Alpine"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetDefaultDistribution(); !errors.Is(err, ErrNoDefaultDistribution) {
+		t.Errorf("got %v, want ErrNoDefaultDistribution", err)
+	}
+}
@@ -0,0 +1,94 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import "golang.org/x/sys/windows/registry"
+
+// regKey is the part of registry.Key used by this package, so that
+// tests can substitute an in-memory registry. Missing keys and values
+// are reported as registry.ErrNotExist.
+type regKey interface {
+	OpenKey(path string, access uint32) (regKey, error)
+	ReadSubKeyNames() ([]string, error)
+	ReadValueNames() ([]string, error)
+	GetStringValue(name string) (string, error)
+	GetStringsValue(name string) ([]string, error)
+	GetIntegerValue(name string) (uint64, error)
+	SetStringValue(name, value string) error
+	SetStringsValue(name string, value []string) error
+	DeleteValue(name string) error
+	Close() error
+}
+
+// The registry roots used by this package: the current user's hive
+// and HKEY_USERS, which holds the hives of all logged-in users.
+var (
+	userHive  regKey = winKey(registry.CURRENT_USER)
+	usersHive regKey = winKey(registry.USERS)
+)
+
+// winKey implements regKey for an open registry.Key.
+type winKey registry.Key
+
+func (k winKey) OpenKey(path string, access uint32) (regKey, error) {
+	sk, err := registry.OpenKey(registry.Key(k), path, access)
+	if err != nil {
+		return nil, err
+	}
+	return winKey(sk), nil
+}
+
+func (k winKey) ReadSubKeyNames() ([]string, error) {
+	return registry.Key(k).ReadSubKeyNames(-1)
+}
+
+func (k winKey) ReadValueNames() ([]string, error) {
+	return registry.Key(k).ReadValueNames(-1)
+}
+
+func (k winKey) GetStringValue(name string) (string, error) {
+	s, _, err := registry.Key(k).GetStringValue(name)
+	return s, err
+}
+
+func (k winKey) GetStringsValue(name string) ([]string, error) {
+	ss, _, err := registry.Key(k).GetStringsValue(name)
+	return ss, err
+}
+
+func (k winKey) GetIntegerValue(name string) (uint64, error) {
+	i, _, err := registry.Key(k).GetIntegerValue(name)
+	return i, err
+}
+
+func (k winKey) SetStringValue(name, value string) error {
+	return registry.Key(k).SetStringValue(name, value)
+}
+
+func (k winKey) SetStringsValue(name string, value []string) error {
+	return registry.Key(k).SetStringsValue(name, value)
+}
+
+func (k winKey) DeleteValue(name string) error {
+	return registry.Key(k).DeleteValue(name)
+}
+
+func (k winKey) Close() error {
+	return registry.Key(k).Close()
+}
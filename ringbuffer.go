@@ -0,0 +1,73 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import "sync"
+
+// RingBuffer is an io.Writer that retains only the most recent bytes
+// written to it, up to a fixed size. It can be passed to RunWriters
+// to keep the tail of a long-running command's output. RingBuffer is
+// safe for concurrent use, so its contents can be inspected while a
+// process is still writing to it.
+type RingBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	pos  int
+	full bool
+}
+
+// NewRingBuffer returns a RingBuffer retaining up to size bytes. A
+// negative size is treated as 0, which discards everything.
+func NewRingBuffer(size int) *RingBuffer {
+	if size < 0 {
+		size = 0
+	}
+	return &RingBuffer{buf: make([]byte, size)}
+}
+
+// Write appends p, discarding the oldest bytes once the buffer is
+// full. It never fails.
+func (r *RingBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	size := len(r.buf)
+	if size == 0 {
+		return len(p), nil
+	}
+	if len(p) >= size {
+		copy(r.buf, p[len(p)-size:])
+		r.pos, r.full = 0, true
+		return len(p), nil
+	}
+	n := copy(r.buf[r.pos:], p)
+	copy(r.buf, p[n:])
+	if r.pos+len(p) >= size {
+		r.full = true
+	}
+	r.pos = (r.pos + len(p)) % size
+	return len(p), nil
+}
+
+// Bytes returns a copy of the retained bytes, oldest first.
+func (r *RingBuffer) Bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]byte(nil), r.buf[:r.pos]...)
+	}
+	return append(append([]byte(nil), r.buf[r.pos:]...), r.buf[:r.pos]...)
+}
@@ -0,0 +1,105 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		size   int
+		writes []string
+		want   string
+	}{
+		{"empty", 8, nil, ""},
+		{"partial", 8, []string{"abc"}, "abc"},
+		{"exactly full", 4, []string{"ab", "cd"}, "abcd"},
+		{"wrap", 4, []string{"abc", "def"}, "cdef"},
+		{"wrap twice", 3, []string{"ab", "cd", "ef", "g"}, "efg"},
+		{"single large write", 4, []string{"0123456789"}, "6789"},
+		{"large write after wrap", 4, []string{"abc", "de", "0123456789"}, "6789"},
+		{"write of ring size", 4, []string{"ab", "wxyz"}, "wxyz"},
+		{"write after large write", 4, []string{"0123456789", "ab"}, "89ab"},
+		{"many small writes", 5, strings.Split("the quick brown fox", ""), "n fox"},
+		{"zero size", 0, []string{"abc"}, ""},
+		{"negative size", -1, []string{"abc"}, ""},
+	} {
+		r := NewRingBuffer(c.size)
+		for _, w := range c.writes {
+			if n, err := r.Write([]byte(w)); n != len(w) || err != nil {
+				t.Fatalf("%s: Write(%q) = %d, %v", c.name, w, n, err)
+			}
+		}
+		if got := string(r.Bytes()); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestRingBufferBytesIsCopy(t *testing.T) {
+	r := NewRingBuffer(4)
+	r.Write([]byte("abcd"))
+	b := r.Bytes()
+	b[0] = 'X'
+	if got := string(r.Bytes()); got != "abcd" {
+		t.Errorf("got %q after modifying result of Bytes", got)
+	}
+}
+
+// TestRingBufferTail compares the buffer with the tail of everything
+// written for writes of all sizes.
+func TestRingBufferTail(t *testing.T) {
+	const size = 7
+	var all bytes.Buffer
+	r := NewRingBuffer(size)
+	for n := 0; n < 3*size; n++ {
+		p := bytes.Repeat([]byte{byte('a' + n%26)}, n)
+		r.Write(p)
+		all.Write(p)
+		want := all.Bytes()
+		if len(want) > size {
+			want = want[len(want)-size:]
+		}
+		if got := r.Bytes(); !bytes.Equal(got, want) {
+			t.Fatalf("after %d bytes: got %q, want %q", all.Len(), got, want)
+		}
+	}
+}
+
+func TestRingBufferConcurrent(t *testing.T) {
+	r := NewRingBuffer(16)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Write([]byte("0123456789"))
+				r.Bytes()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := len(r.Bytes()); got != 16 {
+		t.Errorf("retained %d bytes, want 16", got)
+	}
+}
@@ -0,0 +1,122 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// RunWithInput runs command in the context of a particular
+// distribution, feeding input to its standard input, and returns its
+// output and exit code. A non-zero exit code is not treated as an
+// error.
+func RunWithInput(name, command string, input []byte) (Result, error) {
+	return runResult(context.Background(), name, command, bytes.NewReader(input))
+}
+
+// RunResultContext runs command like RunWithInput, with standard input
+// copied from input, which may be nil. If ctx is done before the
+// command exits, the process is terminated as with LaunchContext and
+// the output captured until then is returned along with ctx.Err().
+func RunResultContext(ctx context.Context, name, command string, input io.Reader) (Result, error) {
+	return runResult(ctx, name, command, input)
+}
+
+// RunWriters runs command like RunResultContext, but writes its
+// standard output and standard error to stdout and stderr as they are
+// produced, e.g. to a RingBuffer. Nil writers discard the output.
+func RunWriters(ctx context.Context, name, command string, input io.Reader, stdout, stderr io.Writer) (uint32, error) {
+	return run(ctx, name, command, input, stdout, stderr)
+}
+
+// runResult runs command and collects its output in a Result.
+func runResult(ctx context.Context, name, command string, input io.Reader) (res Result, err error) {
+	var outBuf, errBuf bytes.Buffer
+	res.ExitCode, err = run(ctx, name, command, input, &outBuf, &errBuf)
+	res.Stdout, res.Stderr = outBuf.Bytes(), errBuf.Bytes()
+	return
+}
+
+// run runs command with stdin copied from input and its output copied
+// to outW and errW, and returns its exit code. The process is
+// terminated if ctx is done before it exits.
+func run(ctx context.Context, name, command string, input io.Reader, outW, errW io.Writer) (exitCode uint32, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if input == nil {
+		input = bytes.NewReader(nil)
+	}
+	if outW == nil {
+		outW = io.Discard
+	}
+	if errW == nil {
+		errW = io.Discard
+	}
+	stdin, stdout, stderr, h, err := LaunchPipes(name, command, false)
+	if err != nil {
+		return
+	}
+	process := newProcessHandle(h)
+	if ctx.Done() != nil {
+		if err = watchProcess(ctx, h); err != nil {
+			windows.TerminateProcess(h, 1)
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		// Errors are ignored: the process may exit without reading
+		// all of its input, which ends the copy with a broken pipe.
+		// The copy is not waited for since reading from input may
+		// block after the process has exited.
+		io.Copy(stdin, input)
+		stdin.Close()
+	}()
+	go func() {
+		io.Copy(outW, stdout)
+		wg.Done()
+	}()
+	go func() {
+		io.Copy(errW, stderr)
+		wg.Done()
+	}()
+	exitCode, werr := process.Wait()
+	if ctx.Err() != nil {
+		// Another process may still hold the write ends; closing
+		// the read ends cancels the pending reads.
+		stdout.Close()
+		stderr.Close()
+	}
+	wg.Wait()
+	stdout.Close()
+	stderr.Close()
+	if err == nil {
+		err = werr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return
+}
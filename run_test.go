@@ -0,0 +1,84 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunWithInput(t *testing.T) {
+	name := testDistribution(t)
+	input := []byte("hello\nworld\n")
+	res, err := RunWithInput(name, "cat", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 {
+		t.Errorf("exit code = %d, want 0", res.ExitCode)
+	}
+	if !bytes.Equal(res.Stdout, input) {
+		t.Errorf("stdout = %q, want %q", res.Stdout, input)
+	}
+	if len(res.Stderr) != 0 {
+		t.Errorf("stderr = %q, want none", res.Stderr)
+	}
+}
+
+func TestRunWithInputLarge(t *testing.T) {
+	name := testDistribution(t)
+	// More than a pipe buffer, so that input and output have to be
+	// copied concurrently.
+	input := bytes.Repeat([]byte("0123456789abcdef\n"), 64*1024)
+	res, err := RunWithInput(name, "cat", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.Stdout, input) {
+		t.Errorf("got %d bytes of output, want the %d bytes of input", len(res.Stdout), len(input))
+	}
+}
+
+func TestRunResultContext(t *testing.T) {
+	d := Distribution{Name: testDistribution(t)}
+	for _, c := range []struct {
+		name           string
+		script         string
+		input          io.Reader
+		exitCode       uint32
+		stdout, stderr string
+	}{
+		{"success", "cat; echo done", strings.NewReader("in\n"), 0, "in\ndone\n", ""},
+		{"nil input", "echo out; echo err >&2", nil, 0, "out\n", "err\n"},
+		{"non-zero exit", "echo out; echo err >&2; exit 42", nil, 42, "out\n", "err\n"},
+	} {
+		res, err := d.RunResultContext(context.Background(), shellCommand(c.script), c.input)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if res.ExitCode != c.exitCode || string(res.Stdout) != c.stdout || string(res.Stderr) != c.stderr {
+			t.Errorf("%s: got exit code %d, stdout %q, stderr %q; want %d, %q, %q",
+				c.name, res.ExitCode, res.Stdout, res.Stderr, c.exitCode, c.stdout, c.stderr)
+		}
+	}
+}
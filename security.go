@@ -0,0 +1,56 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// securityModuleScript prints the comma-separated list of active
+// Linux security modules. If securityfs is not mounted, it falls back
+// to the individual modules' status files.
+const securityModuleScript = `if [ -r /sys/kernel/security/lsm ]; then
+	cat /sys/kernel/security/lsm
+else
+	[ -e /sys/fs/selinux/enforce ] && echo selinux
+	[ "$(cat /sys/module/apparmor/parameters/enabled 2>/dev/null)" = Y ] && echo apparmor
+fi
+true`
+
+// SecurityModuleStatus reports which mandatory access control module
+// is active inside the distribution: "selinux", "apparmor", or "none".
+// Such a module can cause permission-denied failures that are not
+// explained by regular file permissions.
+func (d Distribution) SecurityModuleStatus() (string, error) {
+	out, err := LaunchCombinedOutput(d.Name, shellCommand(securityModuleScript), false)
+	if err != nil {
+		return "", fmt.Errorf("checking security modules: %w", err)
+	}
+	return parseSecurityModules(string(out)), nil
+}
+
+// parseSecurityModules picks the access control module from a list of
+// security module names separated by commas or newlines.
+func parseSecurityModules(list string) string {
+	for _, m := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		if m == "selinux" || m == "apparmor" {
+			return m
+		}
+	}
+	return "none"
+}
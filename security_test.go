@@ -0,0 +1,38 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import "testing"
+
+func TestParseSecurityModules(t *testing.T) {
+	for _, c := range []struct {
+		name, in, want string
+	}{
+		{"apparmor enabled", "lockdown,capability,landlock,yama,apparmor\n", "apparmor"},
+		{"apparmor from status file", "apparmor\n", "apparmor"},
+		{"selinux enforcing", "capability,selinux,bpf\n", "selinux"},
+		{"selinux from status file", "selinux\r\n", "selinux"},
+		{"both in fallback output", "selinux\napparmor\n", "selinux"},
+		{"neither", "lockdown,capability,landlock,yama,bpf\n", "none"},
+		{"no output", "", "none"},
+		{"similar names", "apparmor2,selinuxfs\n", "none"},
+	} {
+		if got := parseSecurityModules(c.in); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Serializer is an API that performs all calls one at a time on a
+// single OS thread on which COM stays initialized.
+//
+// The package functions are safe for concurrent use: each call locks
+// its goroutine to the OS thread and initializes COM for the
+// multithreaded apartment for the duration of the call. Serializer
+// can be used instead if the WSL API or wslapi.dll in use turns out
+// to misbehave with concurrent calls.
+type Serializer struct {
+	api   API
+	calls chan func()
+	once  sync.Once
+}
+
+// NewSerializer returns a Serializer forwarding calls to api, which is
+// usually Default. The Serializer's thread is released by Close.
+func NewSerializer(api API) *Serializer {
+	s := &Serializer{api: api, calls: make(chan func())}
+	go s.run()
+	return s
+}
+
+// run performs the calls sent to s on a locked OS thread.
+func (s *Serializer) run() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	// If COM cannot be initialized here, each call initializes it
+	// on its own, which reports the error.
+	if uninit, err := initCOM(); err == nil {
+		defer uninit()
+	}
+	for fn := range s.calls {
+		fn()
+	}
+}
+
+// do performs fn on the Serializer's thread and waits for it to
+// return.
+func (s *Serializer) do(fn func()) {
+	done := make(chan struct{})
+	s.calls <- func() {
+		defer close(done)
+		fn()
+	}
+	<-done
+}
+
+// Close stops the Serializer's thread. The Serializer must not be
+// used afterwards. Close may be called more than once.
+func (s *Serializer) Close() error {
+	s.once.Do(func() { close(s.calls) })
+	return nil
+}
+
+// Configure modifies the behavior of a distribution, see
+// ConfigureDistribution.
+func (s *Serializer) Configure(name string, defaultUID uint32, flags DistributionFlags) (err error) {
+	s.do(func() { err = s.api.Configure(name, defaultUID, flags) })
+	return
+}
+
+// GetConfiguration retrieves the current configuration of a
+// distribution, see GetDistributionConfiguration.
+func (s *Serializer) GetConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
+	s.do(func() { version, defaultUID, flags, environment, err = s.api.GetConfiguration(name) })
+	return
+}
+
+// IsRegistered determines if a distribution is registered, see
+// IsDistributionRegistered.
+func (s *Serializer) IsRegistered(name string) (registered bool) {
+	s.do(func() { registered = s.api.IsRegistered(name) })
+	return
+}
+
+// Launch launches a WSL process in the context of a particular
+// distribution, see Launch.
+func (s *Serializer) Launch(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (process Handle, err error) {
+	s.do(func() { process, err = s.api.Launch(name, command, useCwd, stdin, stdout, stderr) })
+	return
+}
+
+// LaunchInteractive launches an interactive WSL process in the
+// context of a particular distribution, see LaunchInteractive. It
+// blocks the Serializer until the interactive process has exited.
+func (s *Serializer) LaunchInteractive(name string, command string, useCwd bool) (exitCode uint32, err error) {
+	s.do(func() { exitCode, err = s.api.LaunchInteractive(name, command, useCwd) })
+	return
+}
+
+// Register registers a new distribution, see RegisterDistribution.
+func (s *Serializer) Register(name string, tarball string) (err error) {
+	s.do(func() { err = s.api.Register(name, tarball) })
+	return
+}
+
+// Unregister unregisters a distribution, see UnregisterDistribution.
+func (s *Serializer) Unregister(name string) (err error) {
+	s.do(func() { err = s.api.Unregister(name) })
+	return
+}
@@ -0,0 +1,91 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSerializerConcurrent(t *testing.T) {
+	f := &fakeAPI{distributions: map[string]*DistributionConfig{}}
+	s := NewSerializer(f)
+	defer s.Close()
+	old := Default
+	Default = s
+	t.Cleanup(func() { Default = old })
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("test-%d", i)
+			if err := RegisterDistribution(name, "rootfs.tar.gz"); err != nil {
+				t.Errorf("Register(%s): %v", name, err)
+				return
+			}
+			if err := ConfigureDistribution(name, uint32(i), DISTRIBUTION_FLAGS_ENABLE_INTEROP); err != nil {
+				t.Errorf("Configure(%s): %v", name, err)
+			}
+			if _, uid, _, _, err := GetDistributionConfiguration(name); err != nil || uid != uint32(i) {
+				t.Errorf("GetConfiguration(%s) = %d, %v", name, uid, err)
+			}
+			if !IsDistributionRegistered(name) {
+				t.Errorf("%s not registered", name)
+			}
+			if _, err := Launch(name, "true", false, InvalidHandle, InvalidHandle, InvalidHandle); err == nil {
+				t.Errorf("Launch(%s) succeeded", name)
+			}
+			if err := UnregisterDistribution(name); err != nil {
+				t.Errorf("Unregister(%s): %v", name, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(f.distributions) != 0 {
+		t.Errorf("%d distributions left", len(f.distributions))
+	}
+}
+
+// TestSerializerLaunchError checks that a failing Launch through a
+// Serializer wrapping the system API does not call back into Default,
+// which would deadlock.
+func TestSerializerLaunchError(t *testing.T) {
+	s := NewSerializer(syscallAPI{})
+	defer s.Close()
+	old := Default
+	Default = s
+	t.Cleanup(func() { Default = old })
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Launch("go-wsl-test-not-registered", "true", false, InvalidHandle, InvalidHandle, InvalidHandle)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Launch succeeded")
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("Launch through Serializer deadlocked")
+	}
+}
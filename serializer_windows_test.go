@@ -0,0 +1,116 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+// threadAPI forwards to a fakeAPI, recording the OS thread of each
+// call. Unlike fakeAPI, its Launch succeeds.
+type threadAPI struct {
+	*fakeAPI
+	mu      sync.Mutex
+	threads map[uint32]int
+}
+
+func (a *threadAPI) record() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.threads[windows.GetCurrentThreadId()]++
+}
+
+func (a *threadAPI) Configure(name string, defaultUID uint32, flags DistributionFlags) error {
+	a.record()
+	return a.fakeAPI.Configure(name, defaultUID, flags)
+}
+
+func (a *threadAPI) GetConfiguration(name string) (uint32, uint32, DistributionFlags, []string, error) {
+	a.record()
+	return a.fakeAPI.GetConfiguration(name)
+}
+
+func (a *threadAPI) IsRegistered(name string) bool {
+	a.record()
+	return a.fakeAPI.IsRegistered(name)
+}
+
+func (a *threadAPI) Launch(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (Handle, error) {
+	a.record()
+	if !a.fakeAPI.IsRegistered(name) {
+		return InvalidHandle, ErrDistributionNotFound
+	}
+	return windows.CurrentProcess(), nil
+}
+
+func (a *threadAPI) LaunchInteractive(name string, command string, useCwd bool) (uint32, error) {
+	a.record()
+	return 0, nil
+}
+
+func (a *threadAPI) Register(name string, tarball string) error {
+	a.record()
+	return a.fakeAPI.Register(name, tarball)
+}
+
+func (a *threadAPI) Unregister(name string) error {
+	a.record()
+	return a.fakeAPI.Unregister(name)
+}
+
+func TestSerializerThread(t *testing.T) {
+	a := &threadAPI{
+		fakeAPI: &fakeAPI{distributions: map[string]*DistributionConfig{}},
+		threads: map[uint32]int{},
+	}
+	s := NewSerializer(a)
+	defer s.Close()
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("test-%d", i)
+			if err := s.Register(name, "rootfs.tar.gz"); err != nil {
+				t.Errorf("Register(%s): %v", name, err)
+			}
+			s.Configure(name, uint32(i), DISTRIBUTION_FLAGS_ENABLE_INTEROP)
+			s.GetConfiguration(name)
+			s.IsRegistered(name)
+			if _, err := s.Launch(name, "true", false, InvalidHandle, InvalidHandle, InvalidHandle); err != nil {
+				t.Errorf("Launch(%s): %v", name, err)
+			}
+			s.LaunchInteractive(name, "true", false)
+			s.Unregister(name)
+		}(i)
+	}
+	wg.Wait()
+	if len(a.threads) != 1 {
+		t.Errorf("calls ran on %d threads: %v", len(a.threads), a.threads)
+	}
+	for _, calls := range a.threads {
+		if calls != 7*n {
+			t.Errorf("%d calls recorded, want %d", calls, 7*n)
+		}
+	}
+}
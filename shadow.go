@@ -0,0 +1,104 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// shadowCommands lists commands that commonly exist both inside a
+// distribution and, without an extension, in Windows directories
+// appended to $PATH by DISTRIBUTION_FLAGS_APPEND_NT_PATH.
+var shadowCommands = []string{
+	"python", "python3", "pip", "pip3", "node", "npm", "npx",
+	"git", "java", "go", "docker", "kubectl", "code",
+}
+
+// pathShadowScript prints $PATH, followed by a tab-separated line
+// with the command name and the directory for each command given as
+// an argument that is found as an executable in a $PATH directory.
+// Which of them take precedence is decided by parsePathShadows.
+const pathShadowScript = `set -f
+printf '%s\n' "$PATH"
+IFS=:
+for d in $PATH; do
+	[ -n "$d" ] || continue
+	for c in "$@"; do
+		[ -f "$d/$c" ] && [ -x "$d/$c" ] && printf '%s\t%s\n' "$c" "$d"
+	done
+done
+true`
+
+// PathShadowConflicts reports common commands for which a Windows
+// executable precedes the Linux one in the $PATH of the distribution's
+// default user, so that running the command inside the distribution
+// starts the Windows program. Each conflict is described as
+// "python: /mnt/c/.../python shadows /usr/bin/python".
+func (d Distribution) PathShadowConflicts() ([]string, error) {
+	command := "/bin/sh -c " + ShellQuote(pathShadowScript) + " sh " + ShellQuoteAll(shadowCommands)
+	out, err := LaunchCombinedOutput(d.Name, command, false)
+	if err != nil {
+		return nil, commandError("checking PATH", out, err)
+	}
+	return parsePathShadows(string(out), shadowCommands), nil
+}
+
+// parsePathShadows converts the output of pathShadowScript into
+// conflict descriptions for commands, in the order of commands. A
+// command conflicts if it is found in a Windows directory (below
+// /mnt/<drive>) before the first Linux directory it is found in.
+// Empty $PATH entries are ignored, like in pathShadowScript.
+func parsePathShadows(out string, commands []string) []string {
+	lines := strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
+	found := make(map[string]bool)
+	for _, line := range lines[1:] {
+		if c, d, ok := strings.Cut(line, "\t"); ok {
+			found[c+"\t"+d] = true
+		}
+	}
+	var conflicts []string
+	for _, c := range commands {
+		var windows string
+		for _, d := range strings.Split(lines[0], ":") {
+			if d == "" || !found[c+"\t"+d] {
+				continue
+			}
+			if isWindowsDir(d) {
+				if windows == "" {
+					windows = d + "/" + c
+				}
+				continue
+			}
+			if windows != "" {
+				conflicts = append(conflicts, fmt.Sprintf("%s: %s shadows %s", c, windows, d+"/"+c))
+			}
+			break
+		}
+	}
+	return conflicts
+}
+
+// isWindowsDir reports whether dir is a drive mounted by WSL below
+// /mnt or one of its subdirectories.
+func isWindowsDir(dir string) bool {
+	rest, ok := strings.CutPrefix(dir, "/mnt/")
+	if !ok || rest == "" || rest[0]|0x20 < 'a' || rest[0]|0x20 > 'z' {
+		return false
+	}
+	return len(rest) == 1 || rest[1] == '/'
+}
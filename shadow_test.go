@@ -0,0 +1,116 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePathShadows(t *testing.T) {
+	const py = "/mnt/c/Users/u/AppData/Local/Programs/Python/Python312"
+	commands := []string{"python", "git", "node", "code"}
+	for _, c := range []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"no conflicts",
+			"/usr/bin:/bin:/mnt/c/Windows\n" +
+				"python\t/usr/bin\ngit\t/usr/bin\ngit\t/mnt/c/Windows\n",
+			nil},
+		{"windows first",
+			py + ":/usr/bin\n" +
+				"python\t" + py + "\npython\t/usr/bin\n",
+			[]string{"python: " + py + "/python shadows /usr/bin/python"}},
+		{"only windows",
+			"/mnt/c/Program Files/nodejs:/usr/bin\n" +
+				"node\t/mnt/c/Program Files/nodejs\n",
+			nil},
+		{"drive root and other drives",
+			"/mnt/d:/mnt/C/tools:/usr/local/bin\n" +
+				"git\t/mnt/C/tools\ngit\t/usr/local/bin\ncode\t/mnt/d\ncode\t/usr/local/bin\n",
+			[]string{
+				"git: /mnt/C/tools/git shadows /usr/local/bin/git",
+				"code: /mnt/d/code shadows /usr/local/bin/code",
+			}},
+		{"first windows directory is reported",
+			"/mnt/c/a:/mnt/c/b:/usr/bin\n" +
+				"git\t/mnt/c/b\ngit\t/mnt/c/a\ngit\t/usr/bin\n",
+			[]string{"git: /mnt/c/a/git shadows /usr/bin/git"}},
+		{"duplicate entries",
+			"/usr/bin:/mnt/c/x:/usr/bin:/mnt/c/x\n" +
+				"python\t/usr/bin\npython\t/mnt/c/x\npython\t/usr/bin\npython\t/mnt/c/x\n",
+			nil},
+		{"duplicate windows entries",
+			"/mnt/c/x:/mnt/c/x:/bin\n" +
+				"python\t/mnt/c/x\npython\t/mnt/c/x\npython\t/bin\n",
+			[]string{"python: /mnt/c/x/python shadows /bin/python"}},
+		{"empty entries",
+			"::/mnt/c/x::/usr/bin:\n" +
+				"git\t/mnt/c/x\ngit\t/usr/bin\n",
+			[]string{"git: /mnt/c/x/git shadows /usr/bin/git"}},
+		{"not below /mnt/<drive>",
+			"/mnt/wsl/bin:/mnt/cc:/usr/bin\n" +
+				"git\t/mnt/wsl/bin\ngit\t/usr/bin\nnode\t/mnt/cc\nnode\t/usr/bin\n",
+			nil},
+		{"CRLF",
+			"/mnt/c/x:/usr/bin\r\ngit\t/mnt/c/x\r\ngit\t/usr/bin\r\n",
+			[]string{"git: /mnt/c/x/git shadows /usr/bin/git"}},
+		{"empty output", "", nil},
+	} {
+		if got := parsePathShadows(c.out, commands); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// TestPathShadowScript runs pathShadowScript with a fixture $PATH.
+func TestPathShadowScript(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh:", err)
+	}
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	for path, mode := range map[string]os.FileMode{
+		filepath.Join(a, "git"):    0o755,
+		filepath.Join(a, "notes"):  0o644,
+		filepath.Join(b, "git"):    0o755,
+		filepath.Join(b, "python"): 0o755,
+	} {
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, nil, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := ":" + a + "::" + b + ":" + filepath.Join(dir, "missing")
+	cmd := exec.Command(sh, "-c", pathShadowScript, "sh", "git", "notes", "python", "node")
+	cmd.Env = []string{"PATH=" + path}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{path, "git\t" + a, "git\t" + b, "python\t" + b}, "\n") + "\n"
+	if string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
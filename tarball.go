@@ -0,0 +1,120 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+	"sort"
+	"strings"
+)
+
+// tarEntry summarizes a tar archive member for comparison.
+type tarEntry struct {
+	typeflag byte
+	mode     int64
+	uid, gid int
+	linkname string
+	sum      [sha256.Size]byte
+}
+
+// DiffTarballs compares two tar archives, such as root filesystem
+// tarballs or distribution exports, by path. Gzip-compressed archives
+// are decompressed transparently. added lists the paths only present
+// in b, removed those only present in a, and modified those whose
+// type, mode, ownership, link target, or content differ. All lists
+// are sorted.
+//
+// The archives are read sequentially and only a content hash is kept
+// per member, so memory use depends on the number of members rather
+// than on the size of the archives.
+func DiffTarballs(a, b io.Reader) (added, removed, modified []string, err error) {
+	var ea, eb map[string]tarEntry
+	if ea, err = readTarEntries(a); err != nil {
+		return
+	}
+	if eb, err = readTarEntries(b); err != nil {
+		return
+	}
+	for path, x := range ea {
+		if y, ok := eb[path]; !ok {
+			removed = append(removed, path)
+		} else if x != y {
+			modified = append(modified, path)
+		}
+	}
+	for path := range eb {
+		if _, ok := ea[path]; !ok {
+			added = append(added, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
+	return
+}
+
+func readTarEntries(r io.Reader) (map[string]tarEntry, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+	entries := make(map[string]tarEntry)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return nil, err
+		}
+		e := tarEntry{
+			typeflag: hdr.Typeflag,
+			mode:     hdr.Mode,
+			uid:      hdr.Uid,
+			gid:      hdr.Gid,
+			linkname: hdr.Linkname,
+		}
+		h.Sum(e.sum[:0])
+		entries[cleanTarPath(hdr.Name)] = e
+	}
+}
+
+// cleanTarPath maps the different spellings of a member name found in
+// tarballs ("./etc/", "etc", "/etc") to the same path ("/etc").
+func cleanTarPath(name string) string {
+	if name == "." {
+		return "/"
+	}
+	name = strings.TrimPrefix(name, "./")
+	return "/" + strings.Trim(name, "/")
+}
@@ -0,0 +1,140 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// tarMember describes a member of a tarball built by makeTarball.
+type tarMember struct {
+	name, body string
+	mode       int64
+}
+
+// makeTarball builds a tar archive of regular files, optionally
+// gzip-compressed.
+func makeTarball(t *testing.T, compress bool, members ...tarMember) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := io.Writer(&buf)
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(&buf)
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	for _, m := range members {
+		mode := m.mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		hdr := &tar.Header{Name: m.name, Mode: mode, Size: int64(len(m.body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(m.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDiffTarballs(t *testing.T) {
+	a := []tarMember{
+		{name: "./etc/hostname", body: "a"},
+		{name: "./etc/passwd", body: "root"},
+		{name: "./bin/sh", body: "elf", mode: 0o755},
+		{name: "./.profile", body: "x"},
+		{name: "./gone", body: "old"},
+	}
+	b := []tarMember{
+		{name: "/etc/hostname", body: "b"},
+		{name: "etc/passwd", body: "root"},
+		{name: "bin/sh", body: "elf", mode: 0o700},
+		{name: ".profile", body: "x"},
+		{name: "new", body: "new"},
+	}
+	for _, c := range []struct{ gzipA, gzipB bool }{{false, false}, {true, true}, {true, false}} {
+		added, removed, modified, err := DiffTarballs(
+			bytes.NewReader(makeTarball(t, c.gzipA, a...)),
+			bytes.NewReader(makeTarball(t, c.gzipB, b...)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"/new"}; !reflect.DeepEqual(added, want) {
+			t.Errorf("%+v: added = %q, want %q", c, added, want)
+		}
+		if want := []string{"/gone"}; !reflect.DeepEqual(removed, want) {
+			t.Errorf("%+v: removed = %q, want %q", c, removed, want)
+		}
+		if want := []string{"/bin/sh", "/etc/hostname"}; !reflect.DeepEqual(modified, want) {
+			t.Errorf("%+v: modified = %q, want %q", c, modified, want)
+		}
+	}
+}
+
+func TestDiffTarballsIdentical(t *testing.T) {
+	m := []tarMember{{name: "./a", body: "1"}, {name: "./b/c", body: "2"}}
+	added, removed, modified, err := DiffTarballs(
+		bytes.NewReader(makeTarball(t, true, m...)), bytes.NewReader(makeTarball(t, false, m...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != nil || removed != nil || modified != nil {
+		t.Errorf("got %q, %q, %q, want no differences", added, removed, modified)
+	}
+}
+
+func TestDiffTarballsInvalid(t *testing.T) {
+	valid := makeTarball(t, false, tarMember{name: "a", body: "1"})
+	if _, _, _, err := DiffTarballs(bytes.NewReader([]byte{0x1f, 0x8b, 0, 0}), bytes.NewReader(valid)); err == nil {
+		t.Error("truncated gzip: no error")
+	}
+}
+
+func TestCleanTarPath(t *testing.T) {
+	for name, want := range map[string]string{
+		".":         "/",
+		"./":        "/",
+		"/":         "/",
+		"./etc/":    "/etc",
+		"etc":       "/etc",
+		"/etc":      "/etc",
+		"./x":       "/x",
+		"/x":        "/x",
+		".profile":  "/.profile",
+		"./.bashrc": "/.bashrc",
+	} {
+		if got := cleanTarPath(name); got != want {
+			t.Errorf("cleanTarPath(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
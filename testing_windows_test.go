@@ -0,0 +1,37 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"os"
+	"testing"
+)
+
+// testDistribution returns the name of the distribution used by tests
+// that launch processes, taken from WSL_TEST_DISTRIBUTION. Such tests
+// are skipped if it is not set.
+func testDistribution(t *testing.T) string {
+	t.Helper()
+	name := os.Getenv("WSL_TEST_DISTRIBUTION")
+	if name == "" {
+		t.Skip("WSL_TEST_DISTRIBUTION not set")
+	}
+	if !IsDistributionRegistered(name) {
+		t.Fatalf("%s: %v", name, ErrDistributionNotFound)
+	}
+	return name
+}
@@ -0,0 +1,73 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Timezone returns the time zone configured inside the distribution,
+// such as "Europe/Berlin". It is taken from the target of the
+// /etc/localtime symlink or, if that is not a symlink, from
+// /etc/timezone.
+func (d Distribution) Timezone() (string, error) {
+	out, err := LaunchCombinedOutput(d.Name,
+		shellCommand("readlink /etc/localtime || cat /etc/timezone"), false)
+	if err != nil {
+		return "", commandError("reading time zone", out, err)
+	}
+	return parseTimezone(string(out))
+}
+
+// parseTimezone extracts the zone name from either a symlink target
+// such as /usr/share/zoneinfo/Europe/Berlin or the contents of
+// /etc/timezone. Symlink targets outside a zoneinfo directory are
+// rejected.
+func parseTimezone(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "zoneinfo/"); i >= 0 {
+		s = s[i+len("zoneinfo/"):]
+	}
+	if s == "" {
+		return "", errors.New("no time zone configured")
+	}
+	if !timezonePattern.MatchString(s) {
+		return "", fmt.Errorf("unrecognized time zone %q", s)
+	}
+	return s, nil
+}
+
+var timezonePattern = regexp.MustCompile(`^[A-Za-z0-9_+-]+(/[A-Za-z0-9_+-]+)*$`)
+
+// SetTimezone configures the time zone inside the distribution by
+// pointing /etc/localtime to the zone file and writing /etc/timezone.
+// The zone has to be present in the distribution's tzdata below
+// /usr/share/zoneinfo. The commands are run as the distribution's
+// default user, which needs to be privileged to do so.
+func (d Distribution) SetTimezone(tz string) error {
+	if !timezonePattern.MatchString(tz) {
+		return fmt.Errorf("invalid time zone %q", tz)
+	}
+	zone := ShellQuote("/usr/share/zoneinfo/" + tz)
+	script := "[ -f " + zone + " ] || { echo unknown time zone >&2; exit 1; }\n" +
+		"ln -sf " + zone + " /etc/localtime && echo " + ShellQuote(tz) + " > /etc/timezone"
+	out, err := LaunchCombinedOutput(d.Name, shellCommand(script), false)
+	return commandError("setting time zone "+tz, out, err)
+}
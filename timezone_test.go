@@ -0,0 +1,49 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import "testing"
+
+func TestParseTimezone(t *testing.T) {
+	for _, c := range []struct {
+		name, in, want string
+	}{
+		{"timezone file", "Europe/Berlin\n", "Europe/Berlin"},
+		{"timezone file CRLF", "America/Argentina/Buenos_Aires\r\n", "America/Argentina/Buenos_Aires"},
+		{"timezone file UTC", "Etc/UTC\n", "Etc/UTC"},
+		{"absolute symlink", "/usr/share/zoneinfo/Europe/Berlin\n", "Europe/Berlin"},
+		{"relative symlink", "../usr/share/zoneinfo/Asia/Tokyo\n", "Asia/Tokyo"},
+		{"symlink to posix zone", "/usr/share/zoneinfo/posix/Etc/GMT+5\n", "posix/Etc/GMT+5"},
+		{"single-component symlink", "/usr/share/zoneinfo/UTC", "UTC"},
+	} {
+		got, err := parseTimezone(c.in)
+		if err != nil || got != c.want {
+			t.Errorf("%s: got %q, %v; want %q", c.name, got, err, c.want)
+		}
+	}
+	for _, in := range []string{
+		"",
+		"\n",
+		"/etc/alternatives/localtime\n",
+		"/usr/share/zoneinfo/\n",
+		"../../some where\n",
+	} {
+		if got, err := parseTimezone(in); err == nil {
+			t.Errorf("%q: got %q, want an error", in, got)
+		}
+	}
+}
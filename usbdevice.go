@@ -0,0 +1,75 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// USBDevice describes a USB device as listed by usbipd-win.
+type USBDevice struct {
+	BusID       string
+	VIDPID      string
+	Description string
+	// State as reported by usbipd, e.g. "Not shared", "Shared", or
+	// "Attached".
+	State string
+}
+
+// usbipdColumnSep separates columns in usbipd's tabular output.
+// Device descriptions may contain single spaces.
+var usbipdColumnSep = regexp.MustCompile(`\s{2,}`)
+
+// parseUSBDevices parses the table of connected devices printed by
+// "usbipd list" (or "usbipd wsl list" in older versions). Columns are
+// located by their header names; other sections such as the list of
+// persisted devices are ignored.
+func parseUSBDevices(out []byte) (devices []USBDevice) {
+	var columns []string
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "" || strings.HasSuffix(line, ":"):
+			columns = nil
+			continue
+		case strings.HasPrefix(line, "BUSID"):
+			columns = usbipdColumnSep.Split(line, -1)
+			continue
+		case columns == nil:
+			continue
+		}
+		var dev USBDevice
+		for i, field := range usbipdColumnSep.Split(line, len(columns)) {
+			switch columns[i] {
+			case "BUSID":
+				dev.BusID = field
+			case "VID:PID":
+				dev.VIDPID = field
+			case "DEVICE":
+				dev.Description = field
+			case "STATE":
+				dev.State = field
+			}
+		}
+		devices = append(devices, dev)
+	}
+	return
+}
@@ -0,0 +1,66 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUSBDevices(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		out  string
+		want []USBDevice
+	}{
+		{
+			name: "usbipd wsl list",
+			out: "BUSID  VID:PID    DEVICE                                                        STATE\r\n" +
+				"1-7    8087:0a2b  Intel(R) Wireless Bluetooth(R)                                Not attached\r\n" +
+				"2-3    046d:c52b  Logitech USB Input Device, USB Input Device                   Attached - Ubuntu\r\n",
+			want: []USBDevice{
+				{BusID: "1-7", VIDPID: "8087:0a2b", Description: "Intel(R) Wireless Bluetooth(R)", State: "Not attached"},
+				{BusID: "2-3", VIDPID: "046d:c52b", Description: "Logitech USB Input Device, USB Input Device", State: "Attached - Ubuntu"},
+			},
+		},
+		{
+			name: "usbipd 4.x",
+			out: "Connected:\n" +
+				"BUSID  VID:PID    DEVICE                                                        STATE\n" +
+				"1-1    0bda:58fd  Integrated Camera, Camera DFU Device                          Not shared\n" +
+				"2-2    0781:5581  USB Mass Storage Device                                       Shared\n" +
+				"2-4    1050:0407  USB Input Device, Microsoft Usbccid Smartcard Reader (WUDF)   Attached\n" +
+				"\n" +
+				"Persisted:\n" +
+				"GUID                                  DEVICE\n" +
+				"6f9f5b2e-8f8e-4e5b-9c39-1f2d5d1a3b4c  USB Serial Device (COM3)\n",
+			want: []USBDevice{
+				{BusID: "1-1", VIDPID: "0bda:58fd", Description: "Integrated Camera, Camera DFU Device", State: "Not shared"},
+				{BusID: "2-2", VIDPID: "0781:5581", Description: "USB Mass Storage Device", State: "Shared"},
+				{BusID: "2-4", VIDPID: "1050:0407", Description: "USB Input Device, Microsoft Usbccid Smartcard Reader (WUDF)", State: "Attached"},
+			},
+		},
+		{
+			name: "no devices",
+			out:  "Connected:\nBUSID  VID:PID    DEVICE                                                        STATE\n\nPersisted:\nGUID                                  DEVICE\n",
+		},
+	} {
+		if got := parseUSBDevices([]byte(c.out)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// ListUSBDevices returns the USB devices connected to the host that
+// can be shared with WSL2 distributions using usbipd-win.
+//
+// See https://github.com/dorssel/usbipd-win
+func ListUSBDevices() ([]USBDevice, error) {
+	out, err := exec.Command("usbipd", "list").Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, ErrUsbipdNotInstalled
+	} else if err != nil {
+		return nil, fmt.Errorf("usbipd list: %w", err)
+	}
+	return parseUSBDevices(out), nil
+}
@@ -0,0 +1,70 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LookupUID returns the UID of the named user inside a distribution,
+// e.g. for use with ConfigureDistribution. If there is no such user,
+// ErrUserNotFound is returned.
+func LookupUID(name, username string) (uint32, error) {
+	command := "id -u -- " + ShellQuote(username)
+	out, err := LaunchCombinedOutput(name, command, false)
+	return parseUID(command, username, out, err)
+}
+
+// parseUID interprets the result of running id -u for username. id
+// exits with 1 if the user does not exist; any other failure is
+// reported along with the output.
+func parseUID(command, username string, out []byte, err error) (uint32, error) {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode == 1 {
+		return 0, fmt.Errorf("%s: %w", username, ErrUserNotFound)
+	} else if err != nil {
+		return 0, commandError(command, out, err)
+	}
+	uid, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: unexpected output %q", command, out)
+	}
+	return uint32(uid), nil
+}
+
+// usernamePattern matches the user names accepted by LaunchAsUser,
+// following the conventions of useradd.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*\$?$`)
+
+// LaunchAsUser launches a WSL process like Launch, running command as
+// the named user instead of the distribution's default user, using
+// runuser. runuser only works for root, so the default user has to be
+// root. If the user does not exist, ErrUserNotFound is returned.
+func LaunchAsUser(name, username, command string, useCwd bool, stdin, stdout, stderr Handle) (Handle, error) {
+	if !usernamePattern.MatchString(username) {
+		return InvalidHandle, fmt.Errorf("invalid user name %q", username)
+	}
+	if _, err := LookupUID(name, username); err != nil {
+		return InvalidHandle, err
+	}
+	return Launch(name, "runuser -u "+ShellQuote(username)+" -- /bin/sh -c "+ShellQuote(command),
+		useCwd, stdin, stdout, stderr)
+}
@@ -0,0 +1,53 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wsl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseUID(t *testing.T) {
+	const command = "id -u -- alice"
+	uid, err := parseUID(command, "alice", []byte("1000\n"), nil)
+	if err != nil || uid != 1000 {
+		t.Errorf("got %d, %v; want 1000", uid, err)
+	}
+
+	_, err = parseUID(command, "alice", []byte("id: 'alice': no such user\n"), &ExitError{ExitCode: 1})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("exit code 1: got %v, want ErrUserNotFound", err)
+	}
+
+	_, err = parseUID(command, "alice", []byte("/bin/sh: id: not found\n"), &ExitError{ExitCode: 127})
+	var exitErr *ExitError
+	if errors.Is(err, ErrUserNotFound) || !errors.As(err, &exitErr) || exitErr.ExitCode != 127 {
+		t.Errorf("exit code 127: got %v, want ExitError", err)
+	} else if !strings.Contains(err.Error(), "id: not found") {
+		t.Errorf("exit code 127: output missing from %q", err)
+	}
+
+	launchErr := &LaunchError{Distribution: "test", Command: command, Err: ErrDistributionNotFound}
+	if _, err = parseUID(command, "alice", nil, launchErr); !errors.Is(err, ErrDistributionNotFound) {
+		t.Errorf("launch failure: got %v", err)
+	}
+
+	if _, err = parseUID(command, "alice", []byte("alice\n"), nil); err == nil {
+		t.Error("non-numeric output accepted")
+	}
+}
@@ -0,0 +1,180 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// walkDir and removeFile give FindOrphanedVHDs and RemoveOrphanedVHD
+// access to the file system; tests replace them.
+var (
+	walkDir    = filepath.WalkDir
+	removeFile = os.Remove
+)
+
+// FindOrphanedVHDs searches searchDirs recursively for .vhdx files
+// that are not located in the BasePath directory of any registered
+// distribution, such as disk images left behind after a distribution
+// has been unregistered. Directories that cannot be read are skipped.
+func FindOrphanedVHDs(searchDirs []string) ([]string, error) {
+	basePaths, err := readBasePaths()
+	if err != nil {
+		return nil, err
+	}
+	var orphans []string
+	for _, dir := range searchDirs {
+		if dir, err = filepath.Abs(dir); err != nil {
+			return nil, err
+		}
+		err = walkDir(dir, func(path string, e fs.DirEntry, err error) error {
+			if err != nil {
+				if e != nil && e.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !e.IsDir() && isVHD(path) && basePaths[normalizePath(filepath.Dir(path))] == "" {
+				orphans = append(orphans, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphanedVHD deletes the .vhdx file at path after checking that
+// it does not belong to a registered distribution. Disk images that
+// are in use cannot be removed.
+func RemoveOrphanedVHD(path string) error {
+	if !isVHD(path) {
+		return fmt.Errorf("%s: not a .vhdx file", path)
+	}
+	basePaths, err := readBasePaths()
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if name := basePaths[normalizePath(filepath.Dir(abs))]; name != "" {
+		return fmt.Errorf("%s: in use by distribution %s", path, name)
+	}
+	return removeFile(abs)
+}
+
+func isVHD(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".vhdx")
+}
+
+// readBasePaths maps the normalized BasePath values of all registered
+// distributions to the distributions' names.
+func readBasePaths() (map[string]string, error) {
+	distributions, err := readDistributions()
+	if err != nil {
+		return nil, err
+	}
+	basePaths := make(map[string]string)
+	for _, d := range distributions {
+		if p := d.normalizedBasePath(); p != "" {
+			basePaths[p] = d.name
+		}
+	}
+	return basePaths, nil
+}
+
+// basePathAbs returns the expanded, absolute BasePath of d without
+// the \\?\ prefix.
+func (d lxssDistribution) basePathAbs() (string, error) {
+	if d.basePath == "" {
+		return "", fmt.Errorf("%s: no BasePath registry value", d.name)
+	}
+	p, err := registry.ExpandString(d.basePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(strings.TrimPrefix(p, `\\?\`))
+}
+
+// normalizedBasePath returns the normalized BasePath of d, or "" if
+// it has none.
+func (d lxssDistribution) normalizedBasePath() string {
+	p, err := d.basePathAbs()
+	if err != nil {
+		return ""
+	}
+	return normalizePath(p)
+}
+
+// GetDistributionBasePath returns the absolute path of the directory
+// holding the named distribution's files, as recorded in its BasePath
+// registry value: for WSL2, the directory containing ext4.vhdx, for
+// WSL1, the directory containing the rootfs tree.
+func GetDistributionBasePath(name string) (string, error) {
+	d, err := findDistribution(name)
+	if err != nil {
+		return "", err
+	}
+	return d.basePathAbs()
+}
+
+// DetectDuplicateBasePaths returns groups of registered distributions
+// that share the same BasePath, which indicates a corrupted
+// registration, e.g. after a failed import. Unregistering one of them
+// removes the files of the others. Each group is sorted by name.
+func DetectDuplicateBasePaths() ([][]Distribution, error) {
+	distributions, err := readDistributions()
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string][]Distribution)
+	for _, d := range distributions {
+		if p := d.normalizedBasePath(); p != "" {
+			byPath[p] = append(byPath[p], Distribution{Name: d.name})
+		}
+	}
+	var groups [][]Distribution
+	for _, ds := range byPath {
+		if len(ds) < 2 {
+			continue
+		}
+		sort.Slice(ds, func(i, j int) bool { return ds[i].Name < ds[j].Name })
+		groups = append(groups, ds)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0].Name < groups[j][0].Name })
+	return groups, nil
+}
+
+// normalizePath turns path into a form suitable for comparing Windows
+// paths: without the \\?\ prefix, cleaned, and lower-case.
+func normalizePath(path string) string {
+	path = strings.TrimPrefix(path, `\\?\`)
+	return strings.ToLower(filepath.Clean(path))
+}
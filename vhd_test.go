@@ -0,0 +1,141 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// vhdRoot is the directory at which the fixture file system of
+// useFakeFiles appears.
+const vhdRoot = `C:\wsl`
+
+// useFakeFiles makes FindOrphanedVHDs and RemoveOrphanedVHD see fsys
+// at vhdRoot for the duration of the test. It returns the paths that
+// have been removed.
+func useFakeFiles(t *testing.T, fsys fstest.MapFS) *[]string {
+	t.Helper()
+	oldWalk, oldRemove := walkDir, removeFile
+	t.Cleanup(func() { walkDir, removeFile = oldWalk, oldRemove })
+	walkDir = func(dir string, fn fs.WalkDirFunc) error {
+		rel, err := filepath.Rel(vhdRoot, dir)
+		if err != nil {
+			return err
+		}
+		return fs.WalkDir(fsys, filepath.ToSlash(rel), func(path string, e fs.DirEntry, err error) error {
+			return fn(filepath.Join(vhdRoot, filepath.FromSlash(path)), e, err)
+		})
+	}
+	var removed []string
+	removeFile = func(path string) error {
+		removed = append(removed, path)
+		return nil
+	}
+	return &removed
+}
+
+// vhdFixture registers distributions in a fake registry whose
+// BasePaths differ from the fixture file system in case and prefix,
+// and sets up the file system with useFakeFiles.
+func vhdFixture(t *testing.T) *[]string {
+	t.Helper()
+	hive := newFakeHive()
+	hive.addLxss("{11111111-1111-1111-1111-111111111111}", "Ubuntu", `C:\wsl\Ubuntu`)
+	hive.addLxss("{22222222-2222-2222-2222-222222222222}", "Debian", `\\?\c:\WSL\debian`)
+	useFakeRegistry(t, hive, nil)
+	return useFakeFiles(t, fstest.MapFS{
+		"Ubuntu/ext4.vhdx":     {},
+		"Debian/ext4.vhdx":     {},
+		"Old/ext4.vhdx":        {},
+		"Old/notes.txt":        {},
+		"Old/nested/SWAP.VHDX": {},
+	})
+}
+
+func TestFindOrphanedVHDs(t *testing.T) {
+	vhdFixture(t)
+	got, err := FindOrphanedVHDs([]string{vhdRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`C:\wsl\Old\ext4.vhdx`, `C:\wsl\Old\nested\SWAP.VHDX`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRemoveOrphanedVHD(t *testing.T) {
+	removed := vhdFixture(t)
+	for _, path := range []string{
+		`C:\wsl\Ubuntu\ext4.vhdx`,
+		`C:\WSL\DEBIAN\ext4.vhdx`,
+		`C:\wsl\Old\notes.txt`,
+	} {
+		if err := RemoveOrphanedVHD(path); err == nil {
+			t.Errorf("%s: removed", path)
+		}
+	}
+	if len(*removed) != 0 {
+		t.Fatalf("removed %q", *removed)
+	}
+	if err := RemoveOrphanedVHD(`C:\wsl\Old\ext4.vhdx`); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`C:\wsl\Old\ext4.vhdx`}; !reflect.DeepEqual(*removed, want) {
+		t.Errorf("removed %q, want %q", *removed, want)
+	}
+}
+
+func TestDetectDuplicateBasePaths(t *testing.T) {
+	hive := newFakeHive()
+	hive.addLxss("{11111111-1111-1111-1111-111111111111}", "Ubuntu", `C:\WSL\Shared`)
+	hive.addLxss("{22222222-2222-2222-2222-222222222222}", "Alpine", `\\?\c:\wsl\shared\`)
+	hive.addLxss("{33333333-3333-3333-3333-333333333333}", "Debian", `C:\wsl\Debian`)
+	hive.addLxss("{44444444-4444-4444-4444-444444444444}", "Kali", `C:\wsl\Kali`)
+	hive.addLxss("{55555555-5555-5555-5555-555555555555}", "Arch", `C:\wsl\kali\.`)
+	hive.addLxss("{66666666-6666-6666-6666-666666666666}", "NoBase", "")
+	hive.addLxss("{77777777-7777-7777-7777-777777777777}", "AlsoNoBase", "")
+	useFakeRegistry(t, hive, nil)
+
+	got, err := DetectDuplicateBasePaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]Distribution{
+		{{Name: "Alpine"}, {Name: "Ubuntu"}},
+		{{Name: "Arch"}, {Name: "Kali"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDetectDuplicateBasePathsNone(t *testing.T) {
+	hive := newFakeHive()
+	hive.addLxss("{11111111-1111-1111-1111-111111111111}", "Ubuntu", `C:\wsl\Ubuntu`)
+	hive.addLxss("{22222222-2222-2222-2222-222222222222}", "Ubuntu-Old", `C:\wsl\Ubuntu-Old`)
+	useFakeRegistry(t, hive, nil)
+	if got, err := DetectDuplicateBasePaths(); err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v; want no groups", got, err)
+	}
+}
@@ -0,0 +1,44 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// VMPlatformEnabled determines whether the VirtualMachinePlatform
+// optional Windows feature required by WSL2 is enabled. A feature
+// that has been enabled but is waiting for a reboot is reported as
+// not enabled.
+//
+// The state is queried using DISM, which requires elevated
+// privileges.
+func VMPlatformEnabled() (bool, error) {
+	out, err := exec.Command("dism.exe", "/English", "/Online",
+		"/Get-FeatureInfo", "/FeatureName:VirtualMachinePlatform").Output()
+	if err != nil {
+		return false, fmt.Errorf("dism: %w", err)
+	}
+	state, err := parseDISMFeatureState(out)
+	if err != nil {
+		return false, err
+	}
+	return state == "Enabled", nil
+}
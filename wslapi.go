@@ -14,148 +14,165 @@
 // You should have received a copy of the GNU Affero General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-// Package wsl implements wrapper functions the Windwos Subsystem For
-// Linux API as documented in
-// https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/_wsl/
+//go:build windows
+
 package wsl
 
 import (
+	"fmt"
 	"golang.org/x/sys/windows"
-	"reflect"
-	"unsafe"
 )
 
-type DistributionFlags uint32
+// Handle is a Windows handle, such as a process handle returned by
+// Launch.
+type Handle = windows.Handle
 
-const (
-	DISTRIBUTION_FLAGS_NONE DistributionFlags = iota
-	DISTRIBUTION_FLAGS_ENABLE_INTEROP
-	DISTRIBUTION_FLAGS_APPEND_NT_PATH
-	DISTRIBUTION_FLAGS_ENABLE_DRIVE_MOUNTING
-)
+// InvalidHandle is returned as the process handle by the launch
+// functions if they fail.
+const InvalidHandle = windows.InvalidHandle
 
-//sys	coTaskMemFree(p unsafe.Pointer) (err error) = Ole32.CoTaskMemFree
+// syscallAPI implements API using wslapi.dll.
+type syscallAPI struct{}
 
-//sys	configureDistribution(distributionName *uint16, defaultUID uint32, wslDistributionFlags uint32) (err error) = wslapi.WslConfigureDistribution
+//sys	coTaskMemFree(p unsafe.Pointer) = Ole32.CoTaskMemFree
 
-// ConfigureDistribution Modifies the behavior of a distribution
-// registered with the Windows Subsystem for Linux.
-//
-// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslconfiguredistribution
-func ConfigureDistribution(name string, defaultUID uint32, flags DistributionFlags) error {
-	n, err := windows.UTF16PtrFromString(name)
+// namePtr validates a distribution name and converts it to UTF-16.
+func namePtr(name string) (*uint16, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	return windows.UTF16PtrFromString(name)
+}
+
+//sys	configureDistribution(distributionName *uint16, defaultUID uint32, wslDistributionFlags uint32) (hr error) = wslapi.WslConfigureDistribution
+
+// Configure implements API.Configure.
+func (syscallAPI) Configure(name string, defaultUID uint32, flags DistributionFlags) error {
+	n, err := namePtr(name)
 	if err != nil {
 		return err
 	}
-	return configureDistribution(n, defaultUID, uint32(flags))
+	return comCall(func() error {
+		return configureDistribution(n, defaultUID, uint32(flags))
+	})
 }
 
-//sys	getDistributionConfiguration(distributionName *uint16, distributionVersion *uint32, defaultUID *uint32,  wslDistributionFlags *uint32, defaultEnvironmentVariables ***uint16, defaultEnvironmentVariableCount *uint32) (err error) = wslapi.WslGetDistributionConfiguration
+//sys	getDistributionConfiguration(distributionName *uint16, distributionVersion *uint32, defaultUID *uint32,  wslDistributionFlags *uint32, defaultEnvironmentVariables ***uint16, defaultEnvironmentVariableCount *uint32) (hr error) = wslapi.WslGetDistributionConfiguration
 
-// GetDistributionConfiguration retrieves the current configuration of
-// a distribution registered with the Windows Subsystem for Linux.
-//
-// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslgetdistributionconfiguration
-func GetDistributionConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
+// GetConfiguration implements API.GetConfiguration.
+func (syscallAPI) GetConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
 	var tmpEnv **uint16
 	var envCount uint32
 	var tmpName *uint16
-	if tmpName, err = windows.UTF16PtrFromString(name); err != nil {
+	if tmpName, err = namePtr(name); err != nil {
 		return
 	}
-	if err = getDistributionConfiguration(tmpName, &version, &defaultUID, (*uint32)(&flags), &tmpEnv, &envCount); err != nil {
+	if err = comCall(func() error {
+		return getDistributionConfiguration(tmpName, &version, &defaultUID, (*uint32)(&flags), &tmpEnv, &envCount)
+	}); err != nil {
 		return
 	}
-	for e, i := uintptr(unsafe.Pointer(tmpEnv)), uintptr(0); i < uintptr(envCount); i++ {
-		var tmpUTF16 []uint16
-		p := e + i*unsafe.Sizeof(tmpEnv)
-		hdr := (*reflect.SliceHeader)(unsafe.Pointer(&tmpUTF16))
-		// Assume that individual environment strings will not be
-		// larger than 4096.
-		hdr.Data, hdr.Len, hdr.Cap = p, 4096, 4096
-		environment = append(environment, windows.UTF16ToString(tmpUTF16))
-		coTaskMemFree(unsafe.Pointer(p))
+	// tmpEnv points to an array of envCount string pointers. Both
+	// the strings and the array itself have been allocated by the
+	// API and must be freed using CoTaskMemFree.
+	if environment, err = readEnvironment(tmpEnv, envCount, coTaskMemFree); err != nil {
+		err = fmt.Errorf("WslGetDistributionConfiguration: %w", err)
 	}
 	return
 }
 
 //sys	isDistributionRegistered(distributionName *uint16) (rv bool) = wslapi.WslIsDistributionRegistered
 
-// IsDistributionRegistered determines if a distribution is registered with the Windows Subsystem for Linux.
-//
-// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslisdistributionregistered
-func IsDistributionRegistered(name string) bool {
-	n, err := windows.UTF16PtrFromString(name)
+// IsRegistered implements API.IsRegistered.
+func (syscallAPI) IsRegistered(name string) bool {
+	n, err := namePtr(name)
 	if err != nil {
 		return false
 	}
-	return isDistributionRegistered(n)
+	var rv bool
+	comCall(func() error {
+		rv = isDistributionRegistered(n)
+		return nil
+	})
+	return rv
 }
 
-//sys	launch(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, stdIn windows.Handle, stdOut windows.Handle, stdErr windows.Handle, process *windows.Handle) (err error) = wslapi.WslLaunch
+//sys	launch(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, stdIn windows.Handle, stdOut windows.Handle, stdErr windows.Handle, process *windows.Handle) (hr error) = wslapi.WslLaunch
 
-// Launch launches a Windows Subsystem for Linux (WSL) process in the context of a particular distribution.
-//
-// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wsllaunch
-func Launch(name string, command string, useCwd bool, stdin, stdout, stderr windows.Handle) (process windows.Handle, err error) {
+// wslLaunch calls WslLaunch; tests replace it.
+var wslLaunch = launch
+
+// Launch implements API.Launch.
+func (syscallAPI) Launch(name string, command string, useCwd bool, stdin, stdout, stderr windows.Handle) (process windows.Handle, err error) {
 	var n, c *uint16
-	if n, err = windows.UTF16PtrFromString(name); err != nil {
-		return
+	if n, err = namePtr(name); err == nil {
+		if c, err = windows.UTF16PtrFromString(command); err == nil {
+			err = comCall(func() error {
+				// Close a handle left by a failed attempt
+				// before retrying.
+				if process != 0 && process != windows.InvalidHandle {
+					windows.CloseHandle(process)
+				}
+				process = 0
+				return wslLaunch(n, c, useCwd, stdin, stdout, stderr, &process)
+			})
+		}
 	}
-	if c, err = windows.UTF16PtrFromString(command); err != nil {
-		return
+	if err != nil {
+		// Do not leak a handle that the API may have returned
+		// despite failing.
+		if process != 0 && process != windows.InvalidHandle {
+			windows.CloseHandle(process)
+		}
+		process = windows.InvalidHandle
+		err = launchError(name, command, err)
 	}
-	launch(n, c, useCwd, stdin, stdout, stderr, &process)
 	return
 }
 
-//sys	launchInteractive(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, exitCode *uint32) (err error) = wslapi.WslLaunchInteractive
+//sys	launchInteractive(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, exitCode *uint32) (hr error) = wslapi.WslLaunchInteractive
 
-// LaunchInteractive Launches an interactive Windows Subsystem for
-// Linux (WSL) process in the context of a particular distribution.
-// This differs from Launch in that the end user will be able to
-// interact with the newly-created process.
-//
-// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wsllaunchinteractive
-func LaunchInteractive(name string, command string, useCwd bool) (exitCode uint32, err error) {
+// LaunchInteractive implements API.LaunchInteractive.
+func (syscallAPI) LaunchInteractive(name string, command string, useCwd bool) (exitCode uint32, err error) {
 	var n, c *uint16
-	if n, err = windows.UTF16PtrFromString(name); err != nil {
-		return
+	if n, err = namePtr(name); err == nil {
+		if c, err = windows.UTF16PtrFromString(command); err == nil {
+			err = comCall(func() error {
+				return launchInteractive(n, c, useCwd, &exitCode)
+			})
+		}
 	}
-	if c, err = windows.UTF16PtrFromString(command); err != nil {
-		return
+	if err != nil {
+		err = launchError(name, command, err)
 	}
-	launchInteractive(n, c, useCwd, &exitCode)
 	return
 }
 
-//sys	registerDistribution(distributionName *uint16, tarGzFilename *uint16) (err error) = wslapi.WslRegisterDistribution
+//sys	registerDistribution(distributionName *uint16, tarGzFilename *uint16) (hr error) = wslapi.WslRegisterDistribution
 
-// RegisterDistribution registers a new distribution with the Windows
-// Subsystem for Linux.
-//
-// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslregisterdistribution
-func RegisterDistribution(name string, tarball string) (err error) {
+// Register implements API.Register.
+func (syscallAPI) Register(name string, tarball string) (err error) {
 	var n, t *uint16
-	if n, err = windows.UTF16PtrFromString(name); err != nil {
+	if n, err = namePtr(name); err != nil {
 		return
 	}
 	if t, err = windows.UTF16PtrFromString(tarball); err != nil {
 		return
 	}
-	return registerDistribution(n, t)
+	return comCall(func() error {
+		return registerDistribution(n, t)
+	})
 }
 
-//sys	unregisterDistribution(distributionName *uint16) (err error) = wslapi.WslUnregisterDistribution
+//sys	unregisterDistribution(distributionName *uint16) (hr error) = wslapi.WslUnregisterDistribution
 
-// UnregisterDistribution unregisters a distribution from the Windows
-// Subsystem for Linux.
-//
-// See https://docs.microsoft.com/en-us/previous-versions/windows/desktop/api/wslapi/nf-wslapi-wslunregisterdistribution
-func UnregisterDistribution(name string, tarball string) (err error) {
+// Unregister implements API.Unregister.
+func (syscallAPI) Unregister(name string) (err error) {
 	var n *uint16
-	if n, err = windows.UTF16PtrFromString(name); err != nil {
+	if n, err = namePtr(name); err != nil {
 		return
 	}
-	return unregisterDistribution(n)
+	return comCall(func() error {
+		return unregisterDistribution(n)
+	})
 }
@@ -0,0 +1,244 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !windows
+
+package wsl
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Handle is a Windows handle, such as a process handle returned by
+// Launch.
+type Handle uintptr
+
+// InvalidHandle is returned as the process handle by the launch
+// functions if they fail.
+const InvalidHandle = ^Handle(0)
+
+// ProcessHandle wraps the handle of a WSL process started by
+// LaunchProcess.
+type ProcessHandle struct{}
+
+// syscallAPI implements API by returning ErrUnsupportedPlatform.
+type syscallAPI struct{}
+
+func (syscallAPI) Configure(name string, defaultUID uint32, flags DistributionFlags) error {
+	return ErrUnsupportedPlatform
+}
+
+func (syscallAPI) GetConfiguration(name string) (version uint32, defaultUID uint32, flags DistributionFlags, environment []string, err error) {
+	err = ErrUnsupportedPlatform
+	return
+}
+
+func (syscallAPI) IsRegistered(name string) bool {
+	return false
+}
+
+func (syscallAPI) Launch(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (process Handle, err error) {
+	process, err = InvalidHandle, ErrUnsupportedPlatform
+	return
+}
+
+func (syscallAPI) LaunchInteractive(name string, command string, useCwd bool) (exitCode uint32, err error) {
+	err = ErrUnsupportedPlatform
+	return
+}
+
+func (syscallAPI) Register(name string, tarball string) error {
+	return ErrUnsupportedPlatform
+}
+
+func (syscallAPI) Unregister(name string) error {
+	return ErrUnsupportedPlatform
+}
+
+func RunRedirect(name, command string, stdinPath, stdoutPath, stderrPath string) (exitCode uint32, err error) {
+	err = ErrUnsupportedPlatform
+	return
+}
+
+func ListUSBDevices() ([]USBDevice, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func VMPlatformEnabled() (bool, error) {
+	return false, ErrUnsupportedPlatform
+}
+
+func SetWSLAPIPath(path string) error {
+	return ErrUnsupportedPlatform
+}
+
+func ListDistributions() ([]string, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func GetDefaultDistribution() (string, error) {
+	return "", ErrUnsupportedPlatform
+}
+
+func SetDefaultDistribution(name string) error {
+	return ErrUnsupportedPlatform
+}
+
+func SafeUnregisterDistribution(name string) error {
+	return ErrUnsupportedPlatform
+}
+
+func AllUsersDistributions() (map[string][]UserDistribution, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func GetWSLVersion(name string) (int, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func SetDistributionEnvironment(name string, env map[string]string) error {
+	return ErrUnsupportedPlatform
+}
+
+func RenameDistribution(oldName, newName string) error {
+	return ErrUnsupportedPlatform
+}
+
+func GetDistributionID(name string) (string, error) {
+	return "", ErrUnsupportedPlatform
+}
+
+func InspectDistribution(name string) (*DistributionInfo, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func ExpectedAutomountDrives() ([]string, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func LaunchProcess(name string, command string, useCwd bool, stdin, stdout, stderr Handle) (*ProcessHandle, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func (p *ProcessHandle) Handle() Handle {
+	return InvalidHandle
+}
+
+func (p *ProcessHandle) Wait() (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func (p *ProcessHandle) Close() error {
+	return nil
+}
+
+func WaitForExit(h Handle) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func LaunchContext(ctx context.Context, name, command string, useCwd bool, stdin, stdout, stderr Handle) (process Handle, err error) {
+	process, err = InvalidHandle, ErrUnsupportedPlatform
+	return
+}
+
+func FindOrphanedVHDs(searchDirs []string) ([]string, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func GetDistributionBasePath(name string) (string, error) {
+	return "", ErrUnsupportedPlatform
+}
+
+func DetectDuplicateBasePaths() ([][]Distribution, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func RemoveOrphanedVHD(path string) error {
+	return ErrUnsupportedPlatform
+}
+
+func LaunchCombinedOutput(name, command string, useCwd bool) ([]byte, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func LaunchCombinedOutputContext(ctx context.Context, name, command string, useCwd bool) ([]byte, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func LaunchPipes(name, command string, useCwd bool) (stdin io.WriteCloser, stdout, stderr io.ReadCloser, process Handle, err error) {
+	process, err = InvalidHandle, ErrUnsupportedPlatform
+	return
+}
+
+func RunWithInput(name, command string, input []byte) (Result, error) {
+	return Result{}, ErrUnsupportedPlatform
+}
+
+func RunResultContext(ctx context.Context, name, command string, input io.Reader) (Result, error) {
+	return Result{}, ErrUnsupportedPlatform
+}
+
+func RunWriters(ctx context.Context, name, command string, input io.Reader, stdout, stderr io.Writer) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func TerminateDistribution(name string) error {
+	return ErrUnsupportedPlatform
+}
+
+func ExportDistribution(name, tarballPath string) error {
+	return ErrUnsupportedPlatform
+}
+
+func ImportDistribution(name, installPath, tarballPath string, version int) error {
+	return ErrUnsupportedPlatform
+}
+
+func SetSparseVHD(name string, sparse bool) error {
+	return ErrUnsupportedPlatform
+}
+
+func SetDistributionVersion(name string, version int) error {
+	return ErrUnsupportedPlatform
+}
+
+func LaunchInteractiveTimeout(name, command string, useCwd bool, timeout time.Duration) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func WindowsBuild() (int, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func Available() (bool, error) {
+	return false, ErrUnsupportedPlatform
+}
+
+func lxssValues(name string) ([]byte, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func LaunchConsole(name, command string, useCwd bool) (Handle, error) {
+	return InvalidHandle, ErrUnsupportedPlatform
+}
+
+func SetRetryPolicy(attempts int, maxWait time.Duration) {}
+
+func initCOM() (uninit func(), err error) {
+	return func() {}, nil
+}
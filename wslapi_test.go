@@ -0,0 +1,159 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestLaunchError(t *testing.T) {
+	const unregistered = "go-wsl-test-not-registered"
+	for _, c := range []struct {
+		name, distribution, command string
+		want                        error
+	}{
+		{"invalid name", "", "true", ErrInvalidDistributionName},
+		{"NUL in command", unregistered, "true\x00false", ErrDistributionNotFound},
+		{"not registered", unregistered, "true", ErrDistributionNotFound},
+	} {
+		process, err := syscallAPI{}.Launch(c.distribution, c.command, false, InvalidHandle, InvalidHandle, InvalidHandle)
+		if process != InvalidHandle {
+			t.Errorf("%s: got handle %v, want InvalidHandle", c.name, process)
+		}
+		var launchErr *LaunchError
+		if !errors.As(err, &launchErr) {
+			t.Errorf("%s: got %v, want LaunchError", c.name, err)
+		} else if launchErr.Distribution != c.distribution || launchErr.Command != c.command {
+			t.Errorf("%s: LaunchError for %q in %q", c.name, launchErr.Command, launchErr.Distribution)
+		} else if prefix := fmt.Sprintf("launching %q in %s: ", c.command, c.distribution); !strings.HasPrefix(err.Error(), prefix) {
+			t.Errorf("%s: message %q does not start with %q", c.name, err, prefix)
+		}
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+// fakeLaunch replaces WslLaunch for the duration of the test. On
+// attempt i, counted from 0, it returns a new event handle as the
+// process along with errs[i]. Before each retry, it checks that the
+// handle of the previous attempt has been closed. It returns a
+// pointer to the handles handed out.
+func fakeLaunch(t *testing.T, errs ...error) *[]windows.Handle {
+	t.Helper()
+	var handles []windows.Handle
+	old := wslLaunch
+	t.Cleanup(func() { wslLaunch = old })
+	wslLaunch = func(name, command *uint16, useCwd bool, stdin, stdout, stderr windows.Handle, process *windows.Handle) error {
+		if len(handles) >= len(errs) {
+			t.Errorf("attempt %d, only %d expected", len(handles)+1, len(errs))
+			return errs[len(errs)-1]
+		}
+		if n := len(handles); n > 0 && isOpen(handles[n-1]) {
+			t.Errorf("handle of attempt %d still open on retry", n)
+		}
+		h, err := windows.CreateEvent(nil, 0, 0, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handles = append(handles, h)
+		*process = h
+		return errs[len(handles)-1]
+	}
+	return &handles
+}
+
+// isOpen reports whether h is an open handle.
+func isOpen(h windows.Handle) bool {
+	_, err := windows.WaitForSingleObject(h, 0)
+	return err == nil
+}
+
+func TestLaunchRetry(t *testing.T) {
+	const (
+		retryLater = syscall.Errno(0x8001010A)
+		invalidArg = syscall.Errno(0x80070057)
+	)
+	setTestRetryPolicy(t, 3, 10*time.Second)
+	// A failed launch checks whether the distribution is registered;
+	// load wslapi.dll and initialize COM beforehand so that this does
+	// not open handles that could take the value of a closed one.
+	syscallAPI{}.IsRegistered("go-wsl-test")
+	for _, c := range []struct {
+		name    string
+		errs    []error
+		wantErr bool
+	}{
+		{"first attempt", []error{nil}, false},
+		{"second attempt", []error{retryLater, nil}, false},
+		{"third attempt", []error{retryLater, retryLater, nil}, false},
+		{"attempts exhausted", []error{retryLater, retryLater, retryLater}, true},
+		{"non-transient after retry", []error{retryLater, invalidArg}, true},
+	} {
+		handles := fakeLaunch(t, c.errs...)
+		process, err := syscallAPI{}.Launch("go-wsl-test", "true", false, InvalidHandle, InvalidHandle, InvalidHandle)
+		if len(*handles) != len(c.errs) {
+			t.Errorf("%s: %d attempts, want %d", c.name, len(*handles), len(c.errs))
+		}
+		last := (*handles)[len(*handles)-1]
+		if c.wantErr {
+			var launchErr *LaunchError
+			if !errors.As(err, &launchErr) {
+				t.Errorf("%s: got %v, want LaunchError", c.name, err)
+			}
+			if process != InvalidHandle {
+				t.Errorf("%s: got handle %v, want InvalidHandle", c.name, process)
+			}
+			if isOpen(last) {
+				t.Errorf("%s: handle of the last attempt leaked", c.name)
+			}
+		} else if err != nil || process != last {
+			t.Errorf("%s: got %v, %v; want the handle of the last attempt", c.name, process, err)
+		} else {
+			windows.CloseHandle(process)
+		}
+	}
+}
+
+func TestIsDistributionRegisteredGoroutine(t *testing.T) {
+	name := testDistribution(t)
+	type result struct{ registered, missing bool }
+	done := make(chan result)
+	go func() {
+		// The thread is discarded when the goroutine exits while
+		// still locked, so that no COM state is left behind.
+		runtime.LockOSThread()
+		done <- result{IsDistributionRegistered(name), IsDistributionRegistered("go-wsl-test-not-registered")}
+	}()
+	r := <-done
+	if !r.registered {
+		t.Errorf("%s not registered when called from a new goroutine", name)
+	}
+	if r.missing {
+		t.Error("unregistered distribution reported as registered")
+	}
+}
@@ -0,0 +1,196 @@
+// go-wsl, a Golang interface to Windows Services for Linux
+// Copyright (C) 2018  Hilko Bengen
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package wsl
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The WSL API does not cover all operations that wsl.exe offers, so
+// some functions of this package run wsl.exe instead.
+
+// wslExe runs wsl.exe with args and returns an error including its
+// output if it fails.
+func wslExe(args ...string) error {
+	cmd := exec.Command("wsl.exe", args...)
+	// Ask for UTF-8 output; older versions ignore this and write
+	// UTF-16, which decodeWSLOutput handles.
+	cmd.Env = append(os.Environ(), "WSL_UTF8=1")
+	out, err := cmd.CombinedOutput()
+	return commandError("wsl.exe "+strings.Join(args, " "), decodeWSLOutput(out), err)
+}
+
+// decodeWSLOutput converts output of wsl.exe to UTF-8 if it looks
+// like UTF-16LE.
+func decodeWSLOutput(out []byte) []byte {
+	if len(out) < 2 || len(out)%2 != 0 || out[1] != 0 {
+		return out
+	}
+	u := make([]uint16, len(out)/2)
+	for i := range u {
+		u[i] = uint16(out[2*i]) | uint16(out[2*i+1])<<8
+	}
+	return []byte(string(utf16.Decode(u)))
+}
+
+// TerminateDistribution stops the running instance of the named
+// distribution, like "wsl.exe --terminate". If the distribution is
+// not running, this is a no-op.
+//
+// The WSL API has no call for this, so wsl.exe is run.
+func TerminateDistribution(name string) error {
+	if !IsDistributionRegistered(name) {
+		return fmt.Errorf("%s: %w", name, ErrDistributionNotFound)
+	}
+	return wslExe("--terminate", name)
+}
+
+// ExportDistribution writes the root file system of the named
+// distribution as a gzip-compressed tarball to tarballPath, suitable
+// for RegisterDistribution. A running distribution is exported as is.
+//
+// The WSL API has no call for this, so "wsl.exe --export" is run,
+// writing an uncompressed tarball next to tarballPath, which is then
+// compressed. If wsl.exe fails, the error includes its output.
+func ExportDistribution(name, tarballPath string) (err error) {
+	if !IsDistributionRegistered(name) {
+		return fmt.Errorf("%s: %w", name, ErrDistributionNotFound)
+	}
+	if tarballPath, err = filepath.Abs(tarballPath); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(tarballPath), ".wsl-export-*.tar")
+	if err != nil {
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err = wslExe("--export", name, tmp.Name()); err != nil {
+		return
+	}
+	return gzipFile(tmp.Name(), tarballPath)
+}
+
+// ImportDistribution registers a distribution from the tarball at
+// tarballPath, storing its files in the directory installPath, which
+// is created if necessary. version selects WSL1 or WSL2; 0 uses the
+// default version configured for wsl.exe.
+//
+// RegisterDistribution cannot choose the install location, so
+// "wsl.exe --import" is run. If it fails, the error includes its
+// output.
+func ImportDistribution(name, installPath, tarballPath string, version int) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if IsDistributionRegistered(name) {
+		return fmt.Errorf("%s: %w", name, ErrAlreadyExists)
+	}
+	if version < 0 || version > 2 {
+		return fmt.Errorf("invalid WSL version %d", version)
+	}
+	installPath, err := filepath.Abs(installPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(installPath, 0o755); err != nil {
+		return err
+	}
+	// Check that the directory is writable before wsl.exe starts
+	// unpacking the tarball.
+	f, err := os.CreateTemp(installPath, ".wsl-import-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	os.Remove(f.Name())
+	args := []string{"--import", name, installPath, tarballPath}
+	if version != 0 {
+		args = append(args, "--version", strconv.Itoa(version))
+	}
+	return wslExe(args...)
+}
+
+// SetSparseVHD enables or disables automatic reclaiming of unused
+// space in the virtual disk of the named WSL2 distribution. For WSL1
+// distributions, which have no virtual disk, an error is returned.
+//
+// The WSL API has no call for this, so "wsl.exe --manage --set-sparse"
+// is run, which requires a WSL version that supports it. If it fails,
+// the error includes its output.
+func SetSparseVHD(name string, sparse bool) error {
+	version, err := GetWSLVersion(name)
+	if err != nil {
+		return err
+	}
+	if version != 2 {
+		return fmt.Errorf("%s: sparse VHDs require WSL2", name)
+	}
+	return wslExe("--manage", name, "--set-sparse", strconv.FormatBool(sparse))
+}
+
+// SetDistributionVersion converts the named distribution to WSL1 or
+// WSL2, depending on version. The conversion copies the whole file
+// system and may take a long time, during which the call blocks.
+//
+// The WSL API has no call for this, so "wsl.exe --set-version" is
+// run. If it fails, the error includes its output.
+func SetDistributionVersion(name string, version int) error {
+	if version != 1 && version != 2 {
+		return fmt.Errorf("invalid WSL version %d", version)
+	}
+	if !IsDistributionRegistered(name) {
+		return fmt.Errorf("%s: %w", name, ErrDistributionNotFound)
+	}
+	return wslExe("--set-version", name, strconv.Itoa(version))
+}
+
+// gzipFile writes a gzip-compressed copy of src to dst.
+func gzipFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err != nil {
+		return
+	}
+	return zw.Close()
+}
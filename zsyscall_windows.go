@@ -50,38 +50,23 @@ var (
 	procWslUnregisterDistribution       = modwslapi.NewProc("WslUnregisterDistribution")
 )
 
-func coTaskMemFree(p unsafe.Pointer) (err error) {
-	r1, _, e1 := syscall.Syscall(procCoTaskMemFree.Addr(), 1, uintptr(p), 0, 0)
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
-	}
+func coTaskMemFree(p unsafe.Pointer) {
+	syscall.Syscall(procCoTaskMemFree.Addr(), 1, uintptr(p), 0, 0)
 	return
 }
 
-func configureDistribution(distributionName *uint16, defaultUID uint32, wslDistributionFlags uint32) (err error) {
-	r1, _, e1 := syscall.Syscall(procWslConfigureDistribution.Addr(), 3, uintptr(unsafe.Pointer(distributionName)), uintptr(defaultUID), uintptr(wslDistributionFlags))
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
+func configureDistribution(distributionName *uint16, defaultUID uint32, wslDistributionFlags uint32) (hr error) {
+	r0, _, _ := syscall.Syscall(procWslConfigureDistribution.Addr(), 3, uintptr(unsafe.Pointer(distributionName)), uintptr(defaultUID), uintptr(wslDistributionFlags))
+	if r0 != 0 {
+		hr = syscall.Errno(r0)
 	}
 	return
 }
 
-func getDistributionConfiguration(distributionName *uint16, distributionVersion *uint32, defaultUID *uint32, wslDistributionFlags *uint32, defaultEnvironmentVariables ***uint16, defaultEnvironmentVariableCount *uint32) (err error) {
-	r1, _, e1 := syscall.Syscall6(procWslGetDistributionConfiguration.Addr(), 6, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(distributionVersion)), uintptr(unsafe.Pointer(defaultUID)), uintptr(unsafe.Pointer(wslDistributionFlags)), uintptr(unsafe.Pointer(defaultEnvironmentVariables)), uintptr(unsafe.Pointer(defaultEnvironmentVariableCount)))
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
+func getDistributionConfiguration(distributionName *uint16, distributionVersion *uint32, defaultUID *uint32, wslDistributionFlags *uint32, defaultEnvironmentVariables ***uint16, defaultEnvironmentVariableCount *uint32) (hr error) {
+	r0, _, _ := syscall.Syscall6(procWslGetDistributionConfiguration.Addr(), 6, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(distributionVersion)), uintptr(unsafe.Pointer(defaultUID)), uintptr(unsafe.Pointer(wslDistributionFlags)), uintptr(unsafe.Pointer(defaultEnvironmentVariables)), uintptr(unsafe.Pointer(defaultEnvironmentVariableCount)))
+	if r0 != 0 {
+		hr = syscall.Errno(r0)
 	}
 	return
 }
@@ -92,62 +77,46 @@ func isDistributionRegistered(distributionName *uint16) (rv bool) {
 	return
 }
 
-func launch(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, stdIn windows.Handle, stdOut windows.Handle, stdErr windows.Handle, process *windows.Handle) (err error) {
+func launch(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, stdIn windows.Handle, stdOut windows.Handle, stdErr windows.Handle, process *windows.Handle) (hr error) {
 	var _p0 uint32
 	if useCurrentWorkingDirectory {
 		_p0 = 1
 	} else {
 		_p0 = 0
 	}
-	r1, _, e1 := syscall.Syscall9(procWslLaunch.Addr(), 7, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(command)), uintptr(_p0), uintptr(stdIn), uintptr(stdOut), uintptr(stdErr), uintptr(unsafe.Pointer(process)), 0, 0)
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
+	r0, _, _ := syscall.Syscall9(procWslLaunch.Addr(), 7, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(command)), uintptr(_p0), uintptr(stdIn), uintptr(stdOut), uintptr(stdErr), uintptr(unsafe.Pointer(process)), 0, 0)
+	if r0 != 0 {
+		hr = syscall.Errno(r0)
 	}
 	return
 }
 
-func launchInteractive(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, exitCode *uint32) (err error) {
+func launchInteractive(distributionName *uint16, command *uint16, useCurrentWorkingDirectory bool, exitCode *uint32) (hr error) {
 	var _p0 uint32
 	if useCurrentWorkingDirectory {
 		_p0 = 1
 	} else {
 		_p0 = 0
 	}
-	r1, _, e1 := syscall.Syscall6(procWslLaunchInteractive.Addr(), 4, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(command)), uintptr(_p0), uintptr(unsafe.Pointer(exitCode)), 0, 0)
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
+	r0, _, _ := syscall.Syscall6(procWslLaunchInteractive.Addr(), 4, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(command)), uintptr(_p0), uintptr(unsafe.Pointer(exitCode)), 0, 0)
+	if r0 != 0 {
+		hr = syscall.Errno(r0)
 	}
 	return
 }
 
-func registerDistribution(distributionName *uint16, tarGzFilename *uint16) (err error) {
-	r1, _, e1 := syscall.Syscall(procWslRegisterDistribution.Addr(), 2, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(tarGzFilename)), 0)
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
+func registerDistribution(distributionName *uint16, tarGzFilename *uint16) (hr error) {
+	r0, _, _ := syscall.Syscall(procWslRegisterDistribution.Addr(), 2, uintptr(unsafe.Pointer(distributionName)), uintptr(unsafe.Pointer(tarGzFilename)), 0)
+	if r0 != 0 {
+		hr = syscall.Errno(r0)
 	}
 	return
 }
 
-func unregisterDistribution(distributionName *uint16) (err error) {
-	r1, _, e1 := syscall.Syscall(procWslUnregisterDistribution.Addr(), 1, uintptr(unsafe.Pointer(distributionName)), 0, 0)
-	if r1 == 0 {
-		if e1 != 0 {
-			err = errnoErr(e1)
-		} else {
-			err = syscall.EINVAL
-		}
+func unregisterDistribution(distributionName *uint16) (hr error) {
+	r0, _, _ := syscall.Syscall(procWslUnregisterDistribution.Addr(), 1, uintptr(unsafe.Pointer(distributionName)), 0, 0)
+	if r0 != 0 {
+		hr = syscall.Errno(r0)
 	}
 	return
 }